@@ -0,0 +1,357 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+var numericPrecisionRegex = regexp.MustCompile(`(?i)(decimal|numeric|number)\s*\(\s*(\d+)\s*(?:,\s*(\d+))?\s*\)`)
+
+// columnPrecision parse the numeric precision and scale from the column ddl, ok is false if the column ddl does not
+// declare a precision
+func columnPrecision(col ColumnMeta) (precision, scale int, ok bool) {
+	match := numericPrecisionRegex.FindStringSubmatch(col.ColDDL())
+	if match == nil {
+		match = numericPrecisionRegex.FindStringSubmatch(col.DatabaseTypePretty())
+	}
+	if match == nil {
+		return 0, 0, false
+	}
+
+	precision, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, 0, false
+	}
+	if match[3] != "" {
+		scale, err = strconv.Atoi(match[3])
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return precision, scale, true
+}
+
+// GenerateValidator generate the go source for a Validate() method on the model struct for the table. The method checks
+// NOT NULL fields without a database default are set, string fields fit the column length and numeric fields fit the
+// column precision and scale. The generated code only depends on fmt, math and unicode/utf8.
+func (c *Config) GenerateValidator(dbTable DbTableMeta) (string, error) {
+	fields, err := c.GenerateFieldsTypes(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	structName := c.ReplaceModelNamingTemplate(dbTable.TableName())
+	if structName == "" {
+		return "", fmt.Errorf("table %s does not have a valid struct name, cannot generate validator", dbTable.TableName())
+	}
+	receiver := strings.ToLower(string(structName[0]))
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("// Validate check the %s fields against the %s table column constraints\n", structName, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("func (%s *%s) Validate() error {\n", receiver, structName))
+
+	for _, fi := range fields {
+		col := fi.ColumnMeta
+		field := fmt.Sprintf("%s.%s", receiver, fi.GoFieldName)
+		label := fmt.Sprintf("%s.%s", dbTable.TableName(), col.Name())
+
+		if !col.Nullable() && !col.IsAutoIncrement() && col.DefaultValue() == "" {
+			check := ""
+			switch fi.GoFieldType {
+			case "string":
+				check = fmt.Sprintf("%s == \"\"", field)
+			case "time.Time":
+				check = fmt.Sprintf("%s.IsZero()", field)
+			case "[]byte":
+				check = fmt.Sprintf("len(%s) == 0", field)
+			}
+
+			if check != "" {
+				buf.WriteString(fmt.Sprintf("\tif %s {\n\t\treturn fmt.Errorf(\"%s is required\")\n\t}\n", check, label))
+			}
+		}
+
+		if col.ColumnLength() > 0 {
+			value := ""
+			switch fi.GoFieldType {
+			case "string":
+				value = field
+			case "sql.NullString", "null.String":
+				value = field + ".String"
+			}
+
+			if value != "" {
+				check := fmt.Sprintf("utf8.RuneCountInString(%s) > %d", value, col.ColumnLength())
+				if value != field {
+					check = fmt.Sprintf("%s.Valid && %s", field, check)
+				}
+				buf.WriteString(fmt.Sprintf("\tif %s {\n\t\treturn fmt.Errorf(\"%s exceeds max length of %d\")\n\t}\n", check, label, col.ColumnLength()))
+			}
+		}
+
+		precision, scale, ok := columnPrecision(col)
+		if ok && precision > scale {
+			value := ""
+			switch fi.GoFieldType {
+			case "float32", "float64":
+				value = fmt.Sprintf("float64(%s)", field)
+			case "sql.NullFloat64", "null.Float":
+				value = field + ".Float64"
+			}
+
+			if value != "" {
+				limit := strconv.FormatFloat(math.Pow10(precision-scale), 'g', -1, 64)
+				check := fmt.Sprintf("math.Abs(%s) >= %s", value, limit)
+				if strings.HasSuffix(value, ".Float64") {
+					check = fmt.Sprintf("%s.Valid && %s", field, check)
+				}
+				buf.WriteString(fmt.Sprintf("\tif %s {\n\t\treturn fmt.Errorf(\"%s exceeds numeric(%d,%d)\")\n\t}\n", check, label, precision, scale))
+			}
+		}
+	}
+
+	buf.WriteString("\treturn nil\n}\n")
+	return buf.String(), nil
+}
+
+// nonLiteralDefaults keywords used as column defaults that are evaluated by the database
+var nonLiteralDefaults = []string{"CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "LOCALTIMESTAMP", "LOCALTIME"}
+
+// defaultLiteral render the column default as a go literal for the field type, ok is false if the default is not a
+// simple literal (e.g. a function call) or cannot be assigned to the field type
+func defaultLiteral(goFieldType, value string) (literal string, ok bool) {
+	value = strings.TrimSpace(value)
+	for len(value) >= 2 && strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+
+	if strings.Contains(value, "(") {
+		return "", false
+	}
+	if _, found := FindInSlice(nonLiteralDefaults, strings.ToUpper(value)); found {
+		return "", false
+	}
+
+	quoted := len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'")
+	if quoted {
+		value = strings.Replace(value[1:len(value)-1], "''", "'", -1)
+	}
+
+	switch goFieldType {
+	case "string":
+		return strconv.Quote(value), true
+	case "sql.NullString":
+		return fmt.Sprintf("sql.NullString{String: %s, Valid: true}", strconv.Quote(value)), true
+	case "null.String":
+		return fmt.Sprintf("null.StringFrom(%s)", strconv.Quote(value)), true
+	}
+
+	if quoted {
+		return "", false
+	}
+
+	switch goFieldType {
+	case "int32", "int64":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", false
+		}
+		return value, true
+	case "uint32", "uint64":
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return "", false
+		}
+		return value, true
+	case "sql.NullInt32":
+		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("sql.NullInt32{Int32: %s, Valid: true}", value), true
+	case "sql.NullInt64", "null.Int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", false
+		}
+		if goFieldType == "null.Int" {
+			return fmt.Sprintf("null.IntFrom(%s)", value), true
+		}
+		return fmt.Sprintf("sql.NullInt64{Int64: %s, Valid: true}", value), true
+	case "float32", "float64", "sql.NullFloat64", "null.Float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", false
+		}
+		switch goFieldType {
+		case "sql.NullFloat64":
+			return fmt.Sprintf("sql.NullFloat64{Float64: %s, Valid: true}", value), true
+		case "null.Float":
+			return fmt.Sprintf("null.FloatFrom(%s)", value), true
+		}
+		return value, true
+	case "bool", "sql.NullBool":
+		var b bool
+		switch strings.ToLower(value) {
+		case "true", "t", "1":
+			b = true
+		case "false", "f", "0":
+		default:
+			return "", false
+		}
+		if goFieldType == "sql.NullBool" {
+			return fmt.Sprintf("sql.NullBool{Bool: %t, Valid: true}", b), true
+		}
+		return strconv.FormatBool(b), true
+	}
+	return "", false
+}
+
+// GenerateDefaultConstructor generate the go source for a NewXxx() function returning the model struct for the table
+// with the fields initialized from the column defaults. Defaults that are not simple literals are left as the zero value
+// and noted with a comment.
+func (c *Config) GenerateDefaultConstructor(dbTable DbTableMeta) (string, error) {
+	fields, err := c.GenerateFieldsTypes(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	structName := c.ReplaceModelNamingTemplate(dbTable.TableName())
+	if structName == "" {
+		return "", fmt.Errorf("table %s does not have a valid struct name, cannot generate constructor", dbTable.TableName())
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("// New%s create a new %s initialized with the %s table column defaults\n", structName, structName, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("func New%s() *%s {\n", structName, structName))
+	buf.WriteString(fmt.Sprintf("\treturn &%s{\n", structName))
+
+	for _, fi := range fields {
+		col := fi.ColumnMeta
+		value := col.DefaultValue()
+		if value == "" || strings.EqualFold(value, "NULL") || col.IsAutoIncrement() {
+			continue
+		}
+
+		literal, ok := defaultLiteral(fi.GoFieldType, value)
+		if !ok {
+			buf.WriteString(fmt.Sprintf("\t\t// %s database default %s is not a literal, left as zero value\n", fi.GoFieldName, value))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\t\t%s: %s,\n", fi.GoFieldName, literal))
+	}
+
+	buf.WriteString("\t}\n}\n")
+	return buf.String(), nil
+}
+
+// GenerateEnumTypes generate a string type for each enum column of the table, along with a constant for each enum value
+// and a Valid method reporting whether a value is one of the enum values. Tables without enum columns generate no code.
+func (c *Config) GenerateEnumTypes(dbTable DbTableMeta) (string, error) {
+	fields, err := c.GenerateFieldsTypes(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	structName := c.ReplaceModelNamingTemplate(dbTable.TableName())
+	if structName == "" {
+		return "", fmt.Errorf("table %s does not have a valid struct name, cannot generate enum types", dbTable.TableName())
+	}
+
+	buf := bytes.Buffer{}
+	for _, fi := range fields {
+		col := fi.ColumnMeta
+		values := col.EnumValues()
+		if len(values) == 0 {
+			continue
+		}
+
+		typeName := structName + fi.GoFieldName
+		buf.WriteString(fmt.Sprintf("// %s values of the %s.%s enum column\n", typeName, dbTable.TableName(), col.Name()))
+		buf.WriteString(fmt.Sprintf("type %s string\n\n", typeName))
+
+		names := make([]string, len(values))
+		used := make(map[string]bool)
+		buf.WriteString("const (\n")
+		for i, value := range values {
+			name := typeName + strcase.ToCamel(value)
+			if name == typeName || used[name] || !IsSafeIdentifier(name) {
+				name = fmt.Sprintf("%sValue%d", typeName, i+1)
+			}
+			used[name] = true
+			names[i] = name
+			buf.WriteString(fmt.Sprintf("\t%s %s = %s\n", name, typeName, strconv.Quote(value)))
+		}
+		buf.WriteString(")\n\n")
+
+		buf.WriteString(fmt.Sprintf("// Valid return true if the value is one of the %s values\n", typeName))
+		buf.WriteString(fmt.Sprintf("func (e %s) Valid() bool {\n", typeName))
+		buf.WriteString(fmt.Sprintf("\tswitch e {\n\tcase %s:\n\t\treturn true\n\t}\n\treturn false\n}\n\n", strings.Join(names, ", ")))
+	}
+	return buf.String(), nil
+}
+
+// GenerateScanStruct generate a scan target struct for the records of the table along with a Scan<Struct>Row function
+// scanning a row selected with SELECT * into it. The scan targets are listed in the projected column order of the select
+// generators, an error is returned if a column cannot be mapped to a field so the two cannot drift apart.
+func (c *Config) GenerateScanStruct(dbTable DbTableMeta) (string, error) {
+	fields, err := c.GenerateFieldsTypes(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	structName := c.ReplaceModelNamingTemplate(dbTable.TableName())
+	if structName == "" {
+		return "", fmt.Errorf("table %s does not have a valid struct name, cannot generate scan struct", dbTable.TableName())
+	}
+
+	columns, err := ProjectedColumns(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	byColumn := make(map[string]*FieldInfo)
+	for _, fi := range fields {
+		byColumn[fi.ColumnMeta.Name()] = fi
+	}
+
+	rowName := structName + "Row"
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("// %s scan target of a record selected from the %s table, fields in projection order\n", rowName, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("type %s struct {\n", rowName))
+
+	targets := make([]string, len(columns))
+	for i, name := range columns {
+		fi, ok := byColumn[name]
+		if !ok {
+			return "", fmt.Errorf("table %s column %s cannot be mapped to a field, cannot generate scan struct", dbTable.TableName(), name)
+		}
+		buf.WriteString(fmt.Sprintf("\t%s %s\n", fi.GoFieldName, fi.GoFieldType))
+		targets[i] = "&r." + fi.GoFieldName
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("// Scan%s scan a row selected with SELECT * from the %s table\n", rowName, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("func Scan%s(row *sql.Row) (*%s, error) {\n", rowName, rowName))
+	buf.WriteString(fmt.Sprintf("\tr := &%s{}\n", rowName))
+	buf.WriteString(fmt.Sprintf("\terr := row.Scan(%s)\n", strings.Join(targets, ", ")))
+	buf.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn r, nil\n}\n")
+	return buf.String(), nil
+}
+
+// ColumnToField map the columns of the table to the exported Go field names of the generated model struct, named with
+// the FieldNamingTemplate and deduplicated the same way as the struct fields. Columns whose type cannot be mapped to a
+// Go type have no field and are left out.
+func (c *Config) ColumnToField(dbTable DbTableMeta) (map[string]string, error) {
+	fields, err := c.GenerateFieldsTypes(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(fields))
+	for _, fi := range fields {
+		names[fi.ColumnMeta.Name()] = fi.GoFieldName
+	}
+	return names, nil
+}
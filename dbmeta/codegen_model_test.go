@@ -0,0 +1,223 @@
+package dbmeta
+
+import (
+	"go/format"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_GenerateValidator(t *testing.T) {
+	err := LoadMappings("../template/mapping.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &dbTableMeta{
+		sqlType:   "mysql",
+		tableName: "orders",
+		columns: []*columnMeta{
+			{name: "id", databaseTypeName: "int", columnType: "int", isPrimaryKey: true, isAutoIncrement: true},
+			{name: "code", databaseTypeName: "varchar", columnType: "varchar", columnLen: 16},
+			{name: "note", databaseTypeName: "varchar", columnType: "varchar", columnLen: 255, nullable: true},
+			{name: "total", databaseTypeName: "decimal", columnType: "decimal", colDDL: "decimal(10,2) NOT NULL"},
+		},
+	}
+
+	conf := NewConfig(nil)
+	code, err := conf.GenerateValidator(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = format.Source([]byte(code))
+	if err != nil {
+		t.Fatalf("generated validator does not parse: %v\n%s", err, code)
+	}
+
+	expected := []string{
+		"func (o *Orders) Validate() error {",
+		`if o.Code == "" {`,
+		"if utf8.RuneCountInString(o.Code) > 16 {",
+		"if o.Note.Valid && utf8.RuneCountInString(o.Note.String) > 255 {",
+		"if math.Abs(float64(o.Total)) >= 1e+08 {",
+	}
+	for _, e := range expected {
+		if !strings.Contains(code, e) {
+			t.Errorf("expect: %s, but got %s", e, code)
+		}
+	}
+
+	if strings.Contains(code, "o.ID ==") {
+		t.Errorf("expect auto increment column to not be required, but got %s", code)
+	}
+}
+
+func Test_GenerateDefaultConstructor(t *testing.T) {
+	err := LoadMappings("../template/mapping.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "orders",
+		columns: []*columnMeta{
+			{name: "id", databaseTypeName: "int4", columnType: "int4", isPrimaryKey: true, isAutoIncrement: true},
+			{name: "status", databaseTypeName: "varchar", columnType: "varchar", defaultVal: "new"},
+			{name: "quantity", databaseTypeName: "int4", columnType: "int4", defaultVal: "1"},
+			{name: "paid", databaseTypeName: "bool", columnType: "bool", defaultVal: "false"},
+			{name: "created_at", databaseTypeName: "timestamp", columnType: "timestamp", defaultVal: "now()"},
+		},
+	}
+
+	conf := NewConfig(nil)
+	code, err := conf.GenerateDefaultConstructor(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = format.Source([]byte(code))
+	if err != nil {
+		t.Fatalf("generated constructor does not parse: %v\n%s", err, code)
+	}
+
+	expected := []string{
+		"func NewOrders() *Orders {",
+		`Status: "new",`,
+		"Quantity: 1,",
+		"Paid: false,",
+		"// CreatedAt database default now() is not a literal, left as zero value",
+	}
+	for _, e := range expected {
+		if !strings.Contains(code, e) {
+			t.Errorf("expect: %s, but got %s", e, code)
+		}
+	}
+}
+
+func Test_GenerateEnumTypes(t *testing.T) {
+	err := LoadMappings("../template/mapping.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "orders",
+		columns: []*columnMeta{
+			{name: "id", databaseTypeName: "int4", columnType: "int4", isPrimaryKey: true, isAutoIncrement: true},
+			{name: "status", databaseTypeName: "varchar", columnType: "varchar", enumValues: []string{"pending", "in-transit", "delivered", ""}},
+		},
+	}
+
+	conf := NewConfig(nil)
+	code, err := conf.GenerateEnumTypes(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = format.Source([]byte("package model\n" + code))
+	if err != nil {
+		t.Fatalf("generated enum types do not parse: %v\n%s", err, code)
+	}
+
+	expected := []string{
+		"type OrdersStatus string",
+		`OrdersStatusPending OrdersStatus = "pending"`,
+		`OrdersStatusInTransit OrdersStatus = "in-transit"`,
+		`OrdersStatusValue4 OrdersStatus = ""`,
+		"func (e OrdersStatus) Valid() bool {",
+	}
+	for _, e := range expected {
+		if !strings.Contains(code, e) {
+			t.Errorf("expect: %s, but got %s", e, code)
+		}
+	}
+
+	if parseEnumValues("enum('a','it''s') NOT NULL")[1] != "it's" {
+		t.Errorf("expect: it's, but got %v", parseEnumValues("enum('a','it''s') NOT NULL"))
+	}
+
+	values := parseEnumValues("enum('small','large (xl)') DEFAULT 'small' COMMENT 'size (see docs)'")
+	if !reflect.DeepEqual(values, []string{"small", "large (xl)"}) {
+		t.Errorf("expect: [small large (xl)], but got %v", values)
+	}
+}
+
+func Test_GenerateScanStruct(t *testing.T) {
+	err := LoadMappings("../template/mapping.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "orders",
+		columns: []*columnMeta{
+			{name: "id", databaseTypeName: "int4", columnType: "int4", isPrimaryKey: true, isAutoIncrement: true},
+			{name: "status", databaseTypeName: "varchar", columnType: "varchar"},
+			{name: "note", databaseTypeName: "text", columnType: "text", nullable: true},
+		},
+	}
+
+	conf := NewConfig(nil)
+	code, err := conf.GenerateScanStruct(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = format.Source([]byte("package model\n" + code))
+	if err != nil {
+		t.Fatalf("generated scan struct does not parse: %v\n%s", err, code)
+	}
+
+	expected := []string{
+		"type OrdersRow struct {",
+		"func ScanOrdersRow(row *sql.Row) (*OrdersRow, error) {",
+		"err := row.Scan(&r.ID, &r.Status, &r.Note)",
+	}
+	for _, e := range expected {
+		if !strings.Contains(code, e) {
+			t.Errorf("expect: %s, but got %s", e, code)
+		}
+	}
+
+	table.columns = append(table.columns, &columnMeta{name: "shape", databaseTypeName: "unknown_type", columnType: "unknown_type"})
+	_, err = conf.GenerateScanStruct(table)
+	if err == nil {
+		t.Errorf("expect error for unmapped column")
+	}
+}
+
+func Test_ColumnToField(t *testing.T) {
+	err := LoadMappings("../template/mapping.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "orders",
+		columns: []*columnMeta{
+			{name: "id", databaseTypeName: "int4", columnType: "int4", isPrimaryKey: true},
+			{name: "customer_name", databaseTypeName: "varchar", columnType: "varchar"},
+			{name: "shape", databaseTypeName: "unknown_type", columnType: "unknown_type"},
+		},
+	}
+
+	names, err := NewConfig(nil).ColumnToField(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"id": "ID", "customer_name": "CustomerName"}
+	if len(names) != len(expected) {
+		t.Fatalf("expect: %v, but got %v", expected, names)
+	}
+	for column, field := range expected {
+		if names[column] != field {
+			t.Errorf("expect: %s, but got %s", field, names[column])
+		}
+	}
+}
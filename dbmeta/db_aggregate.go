@@ -0,0 +1,106 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AggregateSpec aggregate computed for each group of records
+type AggregateSpec struct {
+	// Func aggregate function, one of COUNT, SUM, AVG, MIN or MAX
+	Func string
+
+	// Column column aggregated, may be empty for COUNT to count all records
+	Column string
+
+	// Alias optional alias of the aggregate in the result
+	Alias string
+}
+
+var aggregateFuncs = []string{"COUNT", "SUM", "AVG", "MIN", "MAX"}
+
+// expr sql expression of the aggregate
+func (agg AggregateSpec) expr(dbTable DbTableMeta) (string, error) {
+	fn := strings.ToUpper(agg.Func)
+	if _, ok := FindInSlice(aggregateFuncs, fn); !ok {
+		return "", fmt.Errorf("invalid aggregate function %q, cannot generate sql", agg.Func)
+	}
+
+	arg := "*"
+	if agg.Column != "" {
+		if _, ok := findColumn(dbTable, agg.Column); !ok {
+			return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), agg.Column)
+		}
+		arg = agg.Column
+	} else if fn != "COUNT" {
+		return "", fmt.Errorf("aggregate function %s requires a column, cannot generate sql", fn)
+	}
+
+	expr := fmt.Sprintf("%s(%s)", fn, arg)
+	if agg.Alias != "" {
+		err := validateIdentifier("aggregate alias", agg.Alias)
+		if err != nil {
+			return "", err
+		}
+		expr = expr + " AS " + agg.Alias
+	}
+	return expr, nil
+}
+
+// timeBucketFormats DATE_FORMAT and strftime formats used to truncate a timestamp to an interval
+var timeBucketFormats = map[string][2]string{
+	"minute": {"%Y-%m-%d %H:%i:00", "%Y-%m-%d %H:%M:00"},
+	"hour":   {"%Y-%m-%d %H:00:00", "%Y-%m-%d %H:00:00"},
+	"day":    {"%Y-%m-%d", "%Y-%m-%d"},
+	"month":  {"%Y-%m-01", "%Y-%m-01"},
+}
+
+// isTimestampColumn return true if the column holds a date and time
+func isTimestampColumn(col ColumnMeta) bool {
+	typeName := strings.ToLower(col.DatabaseTypeName())
+	return strings.Contains(typeName, "timestamp") || strings.Contains(typeName, "datetime")
+}
+
+// GenerateTimeBucketSQL generate sql to aggregate records into time buckets of the interval (minute, hour, day or
+// month), ordered by the bucket.
+func GenerateTimeBucketSQL(dbTable DbTableMeta, timeCol string, interval string, agg AggregateSpec) (string, error) {
+	col, ok := findColumn(dbTable, timeCol)
+	if !ok {
+		return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), timeCol)
+	}
+
+	if !isTimestampColumn(col) {
+		return "", fmt.Errorf("table %s column %s type %s is not a timestamp, cannot generate sql", dbTable.TableName(), timeCol, col.DatabaseTypeName())
+	}
+
+	interval = strings.ToLower(interval)
+	formats, ok := timeBucketFormats[interval]
+	if !ok {
+		return "", fmt.Errorf("invalid time bucket interval %q, must be one of minute, hour, day or month", interval)
+	}
+
+	aggExpr, err := agg.expr(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	groupBy := "bucket"
+
+	var bucket string
+	switch d.Name() {
+	case "mysql":
+		bucket = fmt.Sprintf("DATE_FORMAT(%s, '%s')", col.Name(), formats[0])
+	case "sqlite3":
+		bucket = fmt.Sprintf("strftime('%s', %s)", formats[1], col.Name())
+	case "mssql":
+		// SQL Server does not allow grouping by a select alias
+		bucket = fmt.Sprintf("DATEADD(%s, DATEDIFF(%s, 0, %s), 0)", interval, interval, col.Name())
+		groupBy = bucket
+	default:
+		bucket = fmt.Sprintf("date_trunc('%s', %s)", interval, col.Name())
+	}
+
+	return fmt.Sprintf("SELECT %s AS bucket, %s FROM %s GROUP BY %s ORDER BY bucket",
+		bucket, aggExpr, d.QuoteIdentifier(dbTable.TableName()), groupBy), nil
+}
@@ -0,0 +1,50 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func testEventsTable(sqlType string) *dbTableMeta {
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	return &dbTableMeta{
+		sqlType:   sqlType,
+		tableName: "events",
+		columns:   []*columnMeta{id, testColumn("created_at", "TIMESTAMP", 1), testColumn("amount", "NUMERIC", 2)},
+	}
+}
+
+func Test_GenerateTimeBucketSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    *dbTableMeta
+		interval string
+		agg      AggregateSpec
+		expected string
+	}{
+		{"postgres", testEventsTable("postgres"), "hour", AggregateSpec{Func: "COUNT"},
+			`SELECT date_trunc('hour', created_at) AS bucket, COUNT(*) FROM "events" GROUP BY bucket ORDER BY bucket`},
+		{"mysql", testEventsTable("mysql"), "day", AggregateSpec{Func: "sum", Column: "amount", Alias: "total"},
+			"SELECT DATE_FORMAT(created_at, '%Y-%m-%d') AS bucket, SUM(amount) AS total FROM `events` GROUP BY bucket ORDER BY bucket"},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateTimeBucketSQL(tt.table, "created_at", tt.interval, tt.agg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+
+	_, err := GenerateTimeBucketSQL(testEventsTable("postgres"), "amount", "hour", AggregateSpec{Func: "COUNT"})
+	if err == nil {
+		t.Errorf("expected error for non timestamp column")
+	}
+
+	_, err = GenerateTimeBucketSQL(testEventsTable("postgres"), "created_at", "week", AggregateSpec{Func: "COUNT"})
+	if err == nil {
+		t.Errorf("expected error for unsupported interval")
+	}
+}
@@ -0,0 +1,106 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// GenerateBatchInsertSQL generate sql inserting a batch of rows where each row may supply a different set of columns.
+// rows holds the columns supplied by each row, the inserted columns are every column supplied by at least one row and a
+// row that omits a column is given DEFAULT. The statement Params list the row values in binding order, named parameters
+// are suffixed with the row number, e.g. name_2. Sqlite does not support DEFAULT in a VALUES list.
+func GenerateBatchInsertSQL(dbTable DbTableMeta, rows []map[string]bool, namedParams bool, opts ...SQLOptions) (*GeneratedStatement, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("table %s batch insert does not have any rows, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateUniqueColumns(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+	if d.Name() == "sqlite3" {
+		return nil, fmt.Errorf("table %s dialect %s does not support DEFAULT values in a batch insert, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	supplied := make(map[string]bool)
+	for i, row := range rows {
+		for name, present := range row {
+			col, ok := findColumn(dbTable, name)
+			if !ok {
+				return nil, fmt.Errorf("table %s does not have a column %s supplied by row %d, cannot generate sql", dbTable.TableName(), name, i+1)
+			}
+			if present && !col.IsAutoIncrement() && opt.includeColumn(col) {
+				supplied[name] = true
+			}
+		}
+	}
+
+	var cols []ColumnMeta
+	for _, col := range dbTable.Columns() {
+		if supplied[col.Name()] {
+			cols = append(cols, col)
+		}
+	}
+
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s batch insert rows cannot generate sql: %w", dbTable.TableName(), ErrNoInsertableColumns)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("INSERT INTO %s (", d.QuoteIdentifier(dbTable.TableName())))
+	for i, col := range cols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(col.Name())
+	}
+	buf.WriteString(") VALUES ")
+
+	var params []StatementParam
+	pos := 1
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString("(")
+		for j, col := range cols {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+
+			if !row[col.Name()] {
+				buf.WriteString("DEFAULT")
+				continue
+			}
+
+			name := fmt.Sprintf("%s_%d", col.Name(), i+1)
+			param := d.Placeholder(pos)
+			if namedParams {
+				param = d.NamedPlaceholder(name)
+			}
+			buf.WriteString(param)
+			params = append(params, newStatementParam(name, col))
+			pos++
+		}
+		buf.WriteString(")")
+	}
+
+	if len(params) > maxBindParams {
+		return nil, fmt.Errorf("table %s batch insert has %d parameters, more than the maximum of %d", dbTable.TableName(), len(params), maxBindParams)
+	}
+
+	return &GeneratedStatement{
+		Name:   StatementName(dbTable, "batch_insert", params),
+		SQL:    opt.annotated(dbTable, "batch_insert", buf.String()),
+		Params: params,
+	}, nil
+}
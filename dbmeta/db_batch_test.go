@@ -0,0 +1,38 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateBatchInsertSQL(t *testing.T) {
+	rows := []map[string]bool{
+		{"name": true, "email": true},
+		{"name": true},
+		{"email": true, "id": true},
+	}
+
+	stmt, err := GenerateBatchInsertSQL(testUsersTable(), rows, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (name, email) VALUES ($1, $2), ($3, DEFAULT), (DEFAULT, $4)`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	names := []string{"name_1", "email_1", "name_2", "email_3"}
+	if len(stmt.Params) != len(names) {
+		t.Fatalf("expect %d params, but got %v", len(names), stmt.Params)
+	}
+	for i, name := range names {
+		if stmt.Params[i].Name != name {
+			t.Errorf("expect: %s, but got %s", name, stmt.Params[i].Name)
+		}
+	}
+
+	_, err = GenerateBatchInsertSQL(testUsersTable(), []map[string]bool{{"missing": true}}, false)
+	if err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+}
@@ -0,0 +1,58 @@
+package dbmeta
+
+import (
+	"fmt"
+	"testing"
+)
+
+// benchTable table with a single integer primary key followed by width - 1 varchar columns
+func benchTable(width int) *dbTableMeta {
+	table := &dbTableMeta{sqlType: "postgres", tableName: "bench"}
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	id.isAutoIncrement = true
+	table.columns = append(table.columns, id)
+	for i := 1; i < width; i++ {
+		table.columns = append(table.columns, testColumn(fmt.Sprintf("col_%d", i), "VARCHAR", i))
+	}
+	return table
+}
+
+func benchmarkGenerator(b *testing.B, generate func(dbTable DbTableMeta) (string, error)) {
+	for _, width := range []int{4, 16, 64} {
+		table := benchTable(width)
+		b.Run(fmt.Sprintf("columns_%d", width), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := generate(table)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateInsertSQL(b *testing.B) {
+	benchmarkGenerator(b, func(dbTable DbTableMeta) (string, error) { return GenerateInsertSQL(dbTable, false) })
+}
+
+func BenchmarkGenerateUpdateSQL(b *testing.B) {
+	benchmarkGenerator(b, func(dbTable DbTableMeta) (string, error) { return GenerateUpdateSQL(dbTable, false) })
+}
+
+func BenchmarkGenerateSelectOneSQL(b *testing.B) {
+	benchmarkGenerator(b, func(dbTable DbTableMeta) (string, error) { return GenerateSelectOneSQL(dbTable, false) })
+}
+
+func BenchmarkGenerateSelectMultiSQL(b *testing.B) {
+	benchmarkGenerator(b, func(dbTable DbTableMeta) (string, error) { return GenerateSelectMultiSQL(dbTable, false) })
+}
+
+func BenchmarkGenerateHardDeleteSQL(b *testing.B) {
+	benchmarkGenerator(b, func(dbTable DbTableMeta) (string, error) { return GenerateHardDeleteSQL(dbTable, false) })
+}
+
+func BenchmarkGenerateUpsertSQL(b *testing.B) {
+	benchmarkGenerator(b, func(dbTable DbTableMeta) (string, error) { return GenerateUpsertSQL(dbTable, false) })
+}
@@ -0,0 +1,23 @@
+package dbmeta
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TableChecksum return a stable digest of the table structure, the ordered column definitions (name, type, nullability,
+// default and primary key) are hashed so the checksum can be compared across environments to detect schema drift.
+func TableChecksum(dbTable DbTableMeta) string {
+	h := sha256.New()
+	for _, col := range dbTable.Columns() {
+		_, _ = fmt.Fprintf(h, "%q %q %t %q %t\n",
+			col.Name(),
+			strings.ToLower(col.DatabaseTypePretty()),
+			col.Nullable(),
+			col.DefaultValue(),
+			col.IsPrimaryKey())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,30 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_TableChecksum(t *testing.T) {
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "users",
+		columns: []*columnMeta{
+			{name: "id", databaseTypeName: "INT4", columnType: "INT4", isPrimaryKey: true},
+			{name: "name", databaseTypeName: "VARCHAR", columnType: "VARCHAR", columnLen: 64, nullable: true},
+		},
+	}
+
+	sum := TableChecksum(table)
+	if len(sum) != 64 {
+		t.Fatalf("expect: 64 hex chars, but got %q", sum)
+	}
+
+	if got := TableChecksum(table); got != sum {
+		t.Errorf("expect: %s, but got %s", sum, got)
+	}
+
+	table.columns[1].nullable = false
+	if got := TableChecksum(table); got == sum {
+		t.Errorf("expect checksum to change when nullability changes, but got %s", got)
+	}
+}
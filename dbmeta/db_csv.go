@@ -0,0 +1,82 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnHeaders return the header row of a csv export of the columns, the raw column names in projection order. If no
+// columns are given every table column is exported in table order.
+func ColumnHeaders(dbTable DbTableMeta, columns []string) []string {
+	if len(columns) > 0 {
+		headers := make([]string, len(columns))
+		copy(headers, columns)
+		return headers
+	}
+
+	var headers []string
+	for _, col := range dbTable.Columns() {
+		headers = append(headers, col.Name())
+	}
+	return headers
+}
+
+// GenerateSelectForCSVSQL generate sql selecting the records of the table for a csv export, projecting the columns in the
+// order of the ColumnHeaders header row so the header and data columns align. The records are ordered by
+// SQLOptions.OrderBy and the primary keys so repeated exports are stable, tenant scoped records are matched on the
+// tenant bound to the only parameter.
+func GenerateSelectForCSVSQL(dbTable DbTableMeta, columns []string, opts ...SQLOptions) (string, error) {
+	primaryCnt := PrimaryKeyCount(dbTable)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	opt.Columns = ColumnHeaders(dbTable, columns)
+	opt.Computed = nil
+
+	seen := make(map[string]bool)
+	for _, name := range opt.Columns {
+		if seen[name] {
+			return "", fmt.Errorf("table %s csv column %s is listed more than once, cannot generate sql", dbTable.TableName(), name)
+		}
+		seen[name] = true
+	}
+
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	orderBy, err := opt.orderBy(d, dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	filter := ""
+	if tenantCol != nil {
+		where := newKeyWhere(d, opt, false, 1)
+		where.addTenant(tenantCol)
+		filter = " WHERE " + where.String()
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s", projection, opt.tableRef(d, dbTable), filter, strings.Join(orderBy, ", "))
+	return opt.routed(opt.annotated(dbTable, "select_csv", opt.hinted(d, dbTable, sql))), nil
+}
@@ -0,0 +1,43 @@
+package dbmeta
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_GenerateSelectForCSVSQL(t *testing.T) {
+	table := testUsersTable()
+
+	headers := ColumnHeaders(table, nil)
+	if !reflect.DeepEqual(headers, []string{"id", "name", "email"}) {
+		t.Errorf("expect: [id name email], but got %v", headers)
+	}
+
+	sql, err := GenerateSelectForCSVSQL(table, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT id, name, email FROM "users" ORDER BY id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectForCSVSQL(table, []string{"email", "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT email, name FROM "users" ORDER BY id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := [][]string{{"missing"}, {"name", "name"}}
+	for _, columns := range invalid {
+		_, err = GenerateSelectForCSVSQL(table, columns)
+		if err == nil {
+			t.Errorf("expect error for csv columns %v", columns)
+		}
+	}
+}
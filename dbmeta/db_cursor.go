@@ -0,0 +1,57 @@
+package dbmeta
+
+import (
+	"fmt"
+)
+
+// GenerateDeclareCursorSQL generate sql declaring a Postgres cursor over every record of the table, so drivers that
+// buffer the whole result set (e.g. lib/pq) can stream it with GenerateFetchCursorSQL. The cursor must be declared
+// and fetched within a transaction. The cursor is always unbounded.
+func GenerateDeclareCursorSQL(dbTable DbTableMeta, cursorName string, opts ...SQLOptions) (string, error) {
+	err := validateIdentifier("cursor name", cursorName)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	if d.Name() != "postgres" {
+		return "", fmt.Errorf("table %s dialect %s does not support cursors, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	// the cursor streams the whole table, DefaultMaxRows does not cap it
+	opt := assureSQLOptions(opts...)
+	selectOpt := opt
+	selectOpt.Unbounded = true
+	selectOpt.OperationComment = false
+	selectOpt.StatementIDComment = false
+	selectOpt.RoutingComment = ""
+
+	selectAll, err := GenerateSelectAllSQL(dbTable, selectOpt)
+	if err != nil {
+		return "", err
+	}
+	return opt.routed(opt.annotated(dbTable, "declare_cursor", fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", cursorName, selectAll))), nil
+}
+
+// GenerateFetchCursorSQL generate sql fetching the next batch of up to count records from a cursor declared with
+// GenerateDeclareCursorSQL, an empty result means the cursor is exhausted
+func GenerateFetchCursorSQL(cursorName string, count int) (string, error) {
+	err := validateIdentifier("cursor name", cursorName)
+	if err != nil {
+		return "", err
+	}
+
+	if count <= 0 {
+		return "", fmt.Errorf("invalid fetch count %d, must be greater than 0", count)
+	}
+	return fmt.Sprintf("FETCH FORWARD %d FROM %s", count, cursorName), nil
+}
+
+// GenerateCloseCursorSQL generate sql closing a cursor declared with GenerateDeclareCursorSQL
+func GenerateCloseCursorSQL(cursorName string) (string, error) {
+	err := validateIdentifier("cursor name", cursorName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CLOSE %s", cursorName), nil
+}
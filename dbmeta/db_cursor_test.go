@@ -0,0 +1,48 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateDeclareCursorSQL(t *testing.T) {
+	sql, err := GenerateDeclareCursorSQL(testUsersTable(), "users_export")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `DECLARE users_export NO SCROLL CURSOR FOR SELECT * FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	DefaultMaxRows = 100
+	defer func() { DefaultMaxRows = 0 }()
+
+	sql, err = GenerateDeclareCursorSQL(testUsersTable(), "users_export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateFetchCursorSQL("users_export", 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "FETCH FORWARD 500 FROM users_export"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateDeclareCursorSQL(testUsersTable(), "export; DROP TABLE users")
+	if err == nil {
+		t.Errorf("expected error for unsafe cursor name")
+	}
+
+	_, err = GenerateFetchCursorSQL("users_export", 0)
+	if err == nil {
+		t.Errorf("expected error for invalid fetch count")
+	}
+}
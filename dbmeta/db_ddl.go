@@ -0,0 +1,247 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// findColumn lookup a column in the table by name
+func findColumn(dbTable DbTableMeta, name string) (ColumnMeta, bool) {
+	for _, col := range dbTable.Columns() {
+		if col.Name() == name {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
+// GenerateRenameTableSQL generate sql to rename a table
+func GenerateRenameTableSQL(dbTable DbTableMeta, newName string) (string, error) {
+	if newName == "" {
+		return "", fmt.Errorf("table %s new name is empty, cannot generate sql", dbTable.TableName())
+	}
+
+	if newName == dbTable.TableName() {
+		return "", fmt.Errorf("table %s new name is the same as the current name, cannot generate sql", dbTable.TableName())
+	}
+
+	d := DialectForTable(dbTable)
+	switch d.Name() {
+	case "mysql":
+		return fmt.Sprintf("RENAME TABLE %s TO %s", d.QuoteIdentifier(dbTable.TableName()), d.QuoteIdentifier(newName)), nil
+	default:
+		return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", d.QuoteIdentifier(dbTable.TableName()), d.QuoteIdentifier(newName)), nil
+	}
+}
+
+// GenerateSelectIntoSQL generate sql creating the new table and filling it with the rows of the source in one step with
+// SELECT ... INTO, e.g. to snapshot a table. Only the listed columns are copied, every column if none are listed. The new
+// table name must be a safe identifier. Supported by SQL Server and Postgres.
+func GenerateSelectIntoSQL(source DbTableMeta, newTable string, columns []string) (string, error) {
+	d := DialectForTable(source)
+	if d.Name() != "mssql" && d.Name() != "postgres" {
+		return "", fmt.Errorf("table %s dialect %s does not support SELECT ... INTO a new table, cannot generate sql", source.TableName(), d.Name())
+	}
+
+	if !safeIdentifierRegex.MatchString(newTable) {
+		return "", fmt.Errorf("table %s new table %q is not a safe identifier, cannot generate sql", source.TableName(), newTable)
+	}
+
+	if newTable == source.TableName() {
+		return "", fmt.Errorf("table %s new table is the same as the source table, cannot generate sql", source.TableName())
+	}
+
+	projection := "*"
+	if len(columns) > 0 {
+		for _, name := range columns {
+			if _, ok := findColumn(source, name); !ok {
+				return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", source.TableName(), name)
+			}
+		}
+		projection = strings.Join(columns, ", ")
+	}
+
+	return fmt.Sprintf("SELECT %s INTO %s FROM %s", projection, d.QuoteIdentifier(newTable), d.QuoteIdentifier(source.TableName())), nil
+}
+
+// GenerateRenameColumnSQL generate sql to rename a column, MySQL requires the full column definition which is taken
+// from the column ddl loaded from the database.
+func GenerateRenameColumnSQL(dbTable DbTableMeta, oldCol, newCol string) (string, error) {
+	col, ok := findColumn(dbTable, oldCol)
+	if !ok {
+		return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), oldCol)
+	}
+
+	if newCol == "" {
+		return "", fmt.Errorf("table %s column %s new name is empty, cannot generate sql", dbTable.TableName(), oldCol)
+	}
+
+	if _, exists := findColumn(dbTable, newCol); exists {
+		return "", fmt.Errorf("table %s already has a column %s, cannot generate sql", dbTable.TableName(), newCol)
+	}
+
+	d := DialectForTable(dbTable)
+	switch d.Name() {
+	case "mysql":
+		colDDL := strings.TrimSpace(col.ColDDL())
+		if colDDL == "" {
+			return "", fmt.Errorf("table %s column %s does not have a column definition, cannot generate sql", dbTable.TableName(), oldCol)
+		}
+
+		return fmt.Sprintf("ALTER TABLE %s CHANGE COLUMN %s %s %s",
+			d.QuoteIdentifier(dbTable.TableName()), d.QuoteIdentifier(oldCol), d.QuoteIdentifier(newCol), colDDL), nil
+	default:
+		return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+			d.QuoteIdentifier(dbTable.TableName()), d.QuoteIdentifier(oldCol), d.QuoteIdentifier(newCol)), nil
+	}
+}
+
+// sqlKeywordDefaults column defaults that are keywords and are not quoted in a column definition
+var sqlKeywordDefaults = []string{"NULL", "TRUE", "FALSE", "CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "LOCALTIMESTAMP", "LOCALTIME"}
+
+// defaultClause render the column default for a column definition, the loaders strip the quotes from string defaults
+// so anything that is not a number, keyword or expression is quoted
+func defaultClause(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	if _, ok := FindInSlice(sqlKeywordDefaults, strings.ToUpper(value)); ok {
+		return value
+	}
+	if strings.Contains(value, "(") || (strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2) {
+		return value
+	}
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// columnDefinition column definition used in a create table statement
+func columnDefinition(d Dialect, col ColumnMeta, inlinePrimaryKey bool) string {
+	buf := bytes.Buffer{}
+	buf.WriteString(d.QuoteIdentifier(col.Name()))
+	buf.WriteString(" ")
+
+	colType := col.DatabaseTypePretty()
+	if precision, scale, ok := columnPrecision(col); ok && !strings.Contains(colType, "(") {
+		colType = fmt.Sprintf("%s(%d,%d)", colType, precision, scale)
+	}
+	buf.WriteString(colType)
+
+	if inlinePrimaryKey {
+		buf.WriteString(" PRIMARY KEY")
+	}
+
+	if col.IsAutoIncrement() {
+		buf.WriteString(" ")
+		buf.WriteString(d.AutoIncrementClause())
+	} else if col.DefaultValue() != "" {
+		buf.WriteString(" DEFAULT ")
+		buf.WriteString(defaultClause(col.DefaultValue()))
+	}
+
+	if !col.Nullable() && !inlinePrimaryKey {
+		buf.WriteString(" NOT NULL")
+	}
+	return buf.String()
+}
+
+// GenerateCreateTableSQL generate sql to create the table in the dialect, the column types are taken from the database
+// the table was loaded from.
+func GenerateCreateTableSQL(dbTable DbTableMeta, d Dialect) (string, error) {
+	if len(dbTable.Columns()) == 0 {
+		return "", fmt.Errorf("table %s does not have any columns, cannot generate sql", dbTable.TableName())
+	}
+
+	primaryKeys := PrimaryKeyNames(dbTable)
+
+	// sqlite only allows AUTOINCREMENT on an INTEGER PRIMARY KEY declared in the column definition
+	inline := ""
+	if d.Name() == "sqlite3" && len(primaryKeys) == 1 {
+		col, _ := findColumn(dbTable, primaryKeys[0])
+		if col.IsAutoIncrement() {
+			inline = col.Name()
+		}
+	}
+
+	var defs []string
+	for _, col := range dbTable.Columns() {
+		defs = append(defs, columnDefinition(d, col, col.Name() == inline))
+	}
+
+	if len(primaryKeys) > 0 && inline == "" {
+		keys := make([]string, len(primaryKeys))
+		for i, name := range primaryKeys {
+			keys[i] = d.QuoteIdentifier(name)
+		}
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(keys, ", ")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", d.QuoteIdentifier(dbTable.TableName()), strings.Join(defs, ",\n  ")), nil
+}
+
+// GenerateCreateIndexSQL generate sql to create an index on the table, sqlite internal index names are replaced as they
+// are reserved
+func GenerateCreateIndexSQL(dbTable DbTableMeta, d Dialect, idx Index) string {
+	name := idx.Name
+	if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+		name = fmt.Sprintf("%s_%s_key", dbTable.TableName(), strings.Join(idx.Columns, "_"))
+	}
+
+	cols := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		cols[i] = d.QuoteIdentifier(col)
+	}
+
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, d.QuoteIdentifier(name), d.QuoteIdentifier(dbTable.TableName()), strings.Join(cols, ", "))
+}
+
+// GenerateMigrationPair generate the contents of the up and down migrations for a new table. The up migration creates
+// the table followed by its indexes and the down migration drops the table, which drops the indexes with it. An error is
+// returned if the table cannot be created, e.g. it does not have any columns.
+func GenerateMigrationPair(dbTable DbTableMeta, dialect Dialect) (up, down string, err error) {
+	createTable, err := GenerateCreateTableSQL(dbTable, dialect)
+	if err != nil {
+		return "", "", err
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(createTable)
+	buf.WriteString(";\n")
+	for _, idx := range dbTable.Indexes() {
+		buf.WriteString(GenerateCreateIndexSQL(dbTable, dialect, idx))
+		buf.WriteString(";\n")
+	}
+
+	return buf.String(), fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", dialect.QuoteIdentifier(dbTable.TableName())), nil
+}
+
+// GenerateColumnCommentsSQL generate the COMMENT ON statements restoring the table comment and the comments of the
+// columns that have one, the table comment is first followed by the columns in table order. Only Postgres supports
+// COMMENT ON.
+func GenerateColumnCommentsSQL(dbTable DbTableMeta) ([]string, error) {
+	d := DialectForTable(dbTable)
+	if d.Name() != "postgres" {
+		return nil, fmt.Errorf("table %s dialect %s does not support COMMENT ON, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	table := d.QuoteIdentifier(dbTable.TableName())
+
+	var statements []string
+	if dbTable.Comment() != "" {
+		statements = append(statements, fmt.Sprintf("COMMENT ON TABLE %s IS %s", table, quoteLiteral(dbTable.Comment())))
+	}
+
+	for _, col := range dbTable.Columns() {
+		if col.Comment() == "" {
+			continue
+		}
+		statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s",
+			table, d.QuoteIdentifier(col.Name()), quoteLiteral(col.Comment())))
+	}
+	return statements, nil
+}
@@ -0,0 +1,145 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func Test_GenerateMigrationPair(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ddl := []string{
+		`CREATE TABLE accounts (id INTEGER PRIMARY KEY AUTOINCREMENT, email VARCHAR(128) NOT NULL UNIQUE, status TEXT DEFAULT 'new', created_at TIMESTAMP)`,
+		`CREATE INDEX accounts_created_at_idx ON accounts (created_at)`,
+	}
+	for _, stmt := range ddl {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dbTable, err := LoadSqliteMeta(db, "sqlite3", "main", "accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, down, err := GenerateMigrationPair(dbTable, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`CREATE TABLE "accounts" (`,
+		`"id" integer PRIMARY KEY AUTOINCREMENT`,
+		`"status" text DEFAULT 'new'`,
+		`CREATE UNIQUE INDEX "accounts_email_key" ON "accounts" ("email");`,
+		`CREATE INDEX "accounts_created_at_idx" ON "accounts" ("created_at");`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(up, e) {
+			t.Errorf("expect: %s, but got %s", e, up)
+		}
+	}
+	if strings.Index(up, "CREATE TABLE") > strings.Index(up, "CREATE INDEX") {
+		t.Errorf("expect indexes to be created after the table, but got %s", up)
+	}
+
+	if down != "DROP TABLE IF EXISTS \"accounts\";\n" {
+		t.Errorf("expect: DROP TABLE IF EXISTS \"accounts\";, but got %s", down)
+	}
+
+	// the migrations must run against a fresh database
+	target, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	for _, migration := range []string{up, down} {
+		_, err = target.Exec(migration)
+		if err != nil {
+			t.Fatalf("%s: %v", migration, err)
+		}
+	}
+
+	_, _, err = GenerateMigrationPair(&dbTableMeta{sqlType: "sqlite3", tableName: "empty"}, DialectSQLite)
+	if err == nil {
+		t.Errorf("expect error for a table without columns")
+	}
+}
+
+func Test_GenerateColumnCommentsSQL(t *testing.T) {
+	table := testUsersTable()
+	table.comment = "registered users"
+	table.columns[2].comment = "user's contact address"
+
+	statements, err := GenerateColumnCommentsSQL(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`COMMENT ON TABLE "users" IS 'registered users'`,
+		`COMMENT ON COLUMN "users"."email" IS 'user''s contact address'`,
+	}
+	if strings.Join(statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expect: %v, but got %v", expected, statements)
+	}
+
+	table.sqlType = "mysql"
+	_, err = GenerateColumnCommentsSQL(table)
+	if err == nil {
+		t.Errorf("expect error for mysql comments")
+	}
+
+	if comment := mysqlParseTableComment("CREATE TABLE `t` (\n  `id` int COMMENT 'x'\n) ENGINE=InnoDB COMMENT='it''s (all)'"); comment != "it's (all)" {
+		t.Errorf("expect: it's (all), but got %s", comment)
+	}
+}
+
+func Test_GenerateSelectIntoSQL(t *testing.T) {
+	table := testUsersTable()
+	table.sqlType = "mssql"
+
+	sql, err := GenerateSelectIntoSQL(table, "users_snapshot", []string{"id", "email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "SELECT id, email INTO [users_snapshot] FROM [users]"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectIntoSQL(testUsersTable(), "users_snapshot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * INTO "users_snapshot" FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectIntoSQL(table, "users; DROP TABLE users", nil)
+	if err == nil {
+		t.Errorf("expect error for unsafe new table name")
+	}
+
+	_, err = GenerateSelectIntoSQL(table, "users_snapshot", []string{"missing"})
+	if err == nil {
+		t.Errorf("expect error for unknown column")
+	}
+
+	table.sqlType = "mysql"
+	_, err = GenerateSelectIntoSQL(table, "users_snapshot", nil)
+	if err == nil {
+		t.Errorf("expect error for dialect without SELECT ... INTO")
+	}
+}
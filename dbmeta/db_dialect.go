@@ -0,0 +1,218 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Dialect sql dialect used when generating sql statements
+type Dialect interface {
+	// Name name of the dialect
+	Name() string
+
+	// QuoteIdentifier quote a table or column name
+	QuoteIdentifier(name string) string
+
+	// Placeholder positional parameter placeholder, pos starts at 1
+	Placeholder(pos int) string
+
+	// NamedPlaceholder named parameter placeholder
+	NamedPlaceholder(name string) string
+
+	// AutoIncrementClause clause used in a column definition to declare an auto increment column
+	AutoIncrementClause() string
+}
+
+var (
+	// DialectPostgres Postgres sql dialect
+	DialectPostgres Dialect = &postgresDialect{}
+
+	// DialectMySQL MySQL sql dialect
+	DialectMySQL Dialect = &mysqlDialect{}
+
+	// DialectSQLite Sqlite3 sql dialect
+	DialectSQLite Dialect = &sqliteDialect{}
+
+	// DialectMSSQL SQL Server sql dialect
+	DialectMSSQL Dialect = &mssqlDialect{}
+)
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = make(map[string]Dialect)
+)
+
+func init() {
+	RegisterDialect("postgres", DialectPostgres)
+	RegisterDialect("mysql", DialectMySQL)
+	RegisterDialect("sqlite3", DialectSQLite)
+	RegisterDialect("sqlite", DialectSQLite)
+	RegisterDialect("mssql", DialectMSSQL)
+}
+
+// RegisterDialect register a dialect for a sql type, any dialect previously registered with the name is replaced. Safe
+// to call while sql is generated concurrently.
+func RegisterDialect(name string, d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[strings.ToLower(name)] = d
+}
+
+// unregisterDialect remove the dialect registered for a sql type
+func unregisterDialect(name string) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	delete(dialects, strings.ToLower(name))
+}
+
+// LookupDialect retrieve the dialect registered for a sql type
+func LookupDialect(name string) (Dialect, error) {
+	dialectsMu.RLock()
+	d, ok := dialects[strings.ToLower(name)]
+	dialectsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sql dialect: %s", name)
+	}
+	return d, nil
+}
+
+// DialectForTable return the dialect registered for the table sql type, DialectPostgres is used if the sql type does
+// not have a registered dialect
+func DialectForTable(dbTable DbTableMeta) Dialect {
+	d, err := LookupDialect(dbTable.SQLType())
+	if err != nil {
+		return DialectPostgres
+	}
+	return d
+}
+
+type postgresDialect struct{}
+
+// Name name of the dialect
+func (d *postgresDialect) Name() string {
+	return "postgres"
+}
+
+// QuoteIdentifier quote a table or column name
+func (d *postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// Placeholder positional parameter placeholder
+func (d *postgresDialect) Placeholder(pos int) string {
+	return fmt.Sprintf("$%d", pos)
+}
+
+// NamedPlaceholder named parameter placeholder
+func (d *postgresDialect) NamedPlaceholder(name string) string {
+	return "@" + name
+}
+
+// AutoIncrementClause clause used in a column definition to declare an auto increment column
+func (d *postgresDialect) AutoIncrementClause() string {
+	return "GENERATED BY DEFAULT AS IDENTITY"
+}
+
+type mysqlDialect struct{}
+
+// Name name of the dialect
+func (d *mysqlDialect) Name() string {
+	return "mysql"
+}
+
+// QuoteIdentifier quote a table or column name
+func (d *mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// Placeholder positional parameter placeholder
+func (d *mysqlDialect) Placeholder(pos int) string {
+	return "?"
+}
+
+// NamedPlaceholder named parameter placeholder
+func (d *mysqlDialect) NamedPlaceholder(name string) string {
+	return ":" + name
+}
+
+// AutoIncrementClause clause used in a column definition to declare an auto increment column
+func (d *mysqlDialect) AutoIncrementClause() string {
+	return "AUTO_INCREMENT"
+}
+
+type sqliteDialect struct{}
+
+// Name name of the dialect
+func (d *sqliteDialect) Name() string {
+	return "sqlite3"
+}
+
+// QuoteIdentifier quote a table or column name
+func (d *sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// Placeholder positional parameter placeholder
+func (d *sqliteDialect) Placeholder(pos int) string {
+	return "?"
+}
+
+// NamedPlaceholder named parameter placeholder
+func (d *sqliteDialect) NamedPlaceholder(name string) string {
+	return "@" + name
+}
+
+// AutoIncrementClause clause used in a column definition to declare an auto increment column
+func (d *sqliteDialect) AutoIncrementClause() string {
+	return "AUTOINCREMENT"
+}
+
+type mssqlDialect struct{}
+
+// Name name of the dialect
+func (d *mssqlDialect) Name() string {
+	return "mssql"
+}
+
+// QuoteIdentifier quote a table or column name
+func (d *mssqlDialect) QuoteIdentifier(name string) string {
+	return "[" + strings.Replace(name, "]", "]]", -1) + "]"
+}
+
+// Placeholder positional parameter placeholder
+func (d *mssqlDialect) Placeholder(pos int) string {
+	return fmt.Sprintf("@p%d", pos)
+}
+
+// NamedPlaceholder named parameter placeholder
+func (d *mssqlDialect) NamedPlaceholder(name string) string {
+	return "@" + name
+}
+
+// AutoIncrementClause clause used in a column definition to declare an auto increment column
+func (d *mssqlDialect) AutoIncrementClause() string {
+	return "IDENTITY(1,1)"
+}
+
+// sqlReservedWords common reserved words that cannot be used unquoted as column names, in lower case
+var sqlReservedWords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "asc": true, "between": true, "by": true, "case": true,
+	"check": true, "column": true, "constraint": true, "create": true, "cross": true, "default": true, "delete": true,
+	"desc": true, "distinct": true, "drop": true, "else": true, "end": true, "except": true, "exists": true,
+	"false": true, "fetch": true, "for": true, "foreign": true, "from": true, "full": true, "grant": true, "group": true,
+	"having": true, "in": true, "index": true, "inner": true, "insert": true, "intersect": true, "into": true,
+	"is": true, "join": true, "key": true, "left": true, "like": true, "limit": true, "not": true, "null": true,
+	"offset": true, "on": true, "or": true, "order": true, "outer": true, "primary": true, "references": true,
+	"right": true, "select": true, "set": true, "table": true, "then": true, "to": true, "true": true, "union": true,
+	"unique": true, "update": true, "user": true, "using": true, "values": true, "when": true, "where": true,
+	"with": true,
+}
+
+// quoteColumn quote the column name if it is a reserved word or not a safe identifier, other names are left as is
+func quoteColumn(d Dialect, name string) string {
+	if !IsSafeIdentifier(name) || sqlReservedWords[strings.ToLower(name)] {
+		return d.QuoteIdentifier(name)
+	}
+	return name
+}
@@ -0,0 +1,74 @@
+package dbmeta
+
+import (
+	"sync"
+	"testing"
+)
+
+type testBracketDialect struct {
+	sqliteDialect
+}
+
+func (d *testBracketDialect) Name() string {
+	return "bracket"
+}
+
+func (d *testBracketDialect) QuoteIdentifier(name string) string {
+	return "[" + name + "]"
+}
+
+func Test_RegisterDialect(t *testing.T) {
+	RegisterDialect("bracket", &testBracketDialect{})
+	defer unregisterDialect("bracket")
+
+	table := testUsersTable()
+	table.sqlType = "bracket"
+
+	sql, err := GenerateHardDeleteSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "DELETE FROM [users] where id = ?"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_RegisterDialectConcurrent(t *testing.T) {
+	defer unregisterDialect("bracket")
+
+	table := testUsersTable()
+	table.sqlType = "bracket"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterDialect("bracket", &testBracketDialect{})
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := GenerateHardDeleteSQL(table, false)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_DialectForTable(t *testing.T) {
+	table := testUsersTable()
+
+	table.sqlType = "mysql"
+	if d := DialectForTable(table); d != DialectMySQL {
+		t.Errorf("expect: %s, but got %s", DialectMySQL.Name(), d.Name())
+	}
+
+	table.sqlType = "unknown"
+	if d := DialectForTable(table); d != DialectPostgres {
+		t.Errorf("expect: %s, but got %s", DialectPostgres.Name(), d.Name())
+	}
+}
@@ -0,0 +1,306 @@
+package dbmeta
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ForeignKey foreign key constraint from the columns of a table to the columns of a referenced table
+type ForeignKey struct {
+	// Name name of the constraint
+	Name string
+
+	// Table table holding the foreign key columns
+	Table string
+
+	// Columns foreign key columns, in constraint order
+	Columns []string
+
+	// RefTable referenced table
+	RefTable string
+
+	// RefColumns referenced columns, in the same order as Columns
+	RefColumns []string
+
+	// OnDelete delete rule of the constraint, e.g. CASCADE or NO ACTION
+	OnDelete string
+}
+
+// LoadForeignKeys fetch the foreign keys of a table, supported for sqlite3, postgres and mysql databases
+func LoadForeignKeys(db *sql.DB, sqlType, sqlDatabase, tableName string) ([]ForeignKey, error) {
+	var query string
+	var args []interface{}
+
+	switch strings.ToLower(sqlType) {
+	case "sqlite3", "sqlite":
+		query = `SELECT id, "table", "from", "to", on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`
+		args = []interface{}{tableName}
+	case "postgres":
+		query = `
+SELECT tc.constraint_name, ccu.table_name, kcu.column_name, ccu.column_name, rc.delete_rule
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu
+  ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+JOIN information_schema.referential_constraints rc
+  ON rc.constraint_schema = tc.constraint_schema AND rc.constraint_name = tc.constraint_name
+JOIN information_schema.key_column_usage ccu
+  ON ccu.constraint_schema = rc.unique_constraint_schema AND ccu.constraint_name = rc.unique_constraint_name
+  AND ccu.ordinal_position = kcu.position_in_unique_constraint
+WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_name = $1
+ORDER BY tc.constraint_name, kcu.ordinal_position`
+		args = []interface{}{tableName}
+	case "mysql":
+		query = `
+SELECT kcu.constraint_name, kcu.referenced_table_name, kcu.column_name, kcu.referenced_column_name, rc.delete_rule
+FROM information_schema.key_column_usage kcu
+JOIN information_schema.referential_constraints rc
+  ON rc.constraint_schema = kcu.table_schema AND rc.constraint_name = kcu.constraint_name
+WHERE kcu.table_schema = ? AND kcu.table_name = ? AND kcu.referenced_table_name IS NOT NULL
+ORDER BY kcu.constraint_name, kcu.ordinal_position`
+		args = []interface{}{sqlDatabase, tableName}
+	default:
+		return nil, fmt.Errorf("loading foreign keys is not supported for %s", sqlType)
+	}
+
+	res, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load foreign keys from %s: %v", tableName, err)
+	}
+	defer res.Close()
+
+	var foreignKeys []ForeignKey
+	index := make(map[string]int)
+	for res.Next() {
+		var name, refTable, column, onDelete string
+		var refColumn sql.NullString
+		err = res.Scan(&name, &refTable, &column, &refColumn, &onDelete)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load foreign keys from %s Scan: %v", tableName, err)
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(foreignKeys)
+			index[name] = i
+			foreignKeys = append(foreignKeys, ForeignKey{Name: name, Table: tableName, RefTable: refTable, OnDelete: onDelete})
+		}
+		foreignKeys[i].Columns = append(foreignKeys[i].Columns, column)
+		foreignKeys[i].RefColumns = append(foreignKeys[i].RefColumns, refColumn.String)
+	}
+	err = res.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite does not report the referenced columns when the foreign key references the primary key implicitly
+	for i, fk := range foreignKeys {
+		if fk.RefColumns[0] != "" {
+			continue
+		}
+
+		refColumns, err := sqliteLoadPrimaryKeyNames(db, fk.RefTable)
+		if err != nil {
+			return nil, err
+		}
+		foreignKeys[i].RefColumns = refColumns
+	}
+	return foreignKeys, nil
+}
+
+// sqliteLoadPrimaryKeyNames fetch the primary key column names of a sqlite table in key order
+func sqliteLoadPrimaryKeyNames(db *sql.DB, tableName string) ([]string, error) {
+	res, err := db.Query(`SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load primary key from %s: %v", tableName, err)
+	}
+	defer res.Close()
+
+	var names []string
+	for res.Next() {
+		var name string
+		err = res.Scan(&name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load primary key from %s Scan: %v", tableName, err)
+		}
+		names = append(names, name)
+	}
+	return names, res.Err()
+}
+
+// GenerateDeleteCascadeSQL generate the delete statements removing a record of the root table, identified by its primary
+// keys, along with the dependent records in the tables. The statements are ordered so children are deleted before their
+// parents and each dependent delete selects its records through the foreign keys back to the root record. Every
+// statement takes the root primary keys as parameters, with ? placeholders the keys are bound once for each path to the
+// root as listed in the statement Params. An error is returned if the foreign keys between the tables form a cycle.
+func GenerateDeleteCascadeSQL(root DbTableMeta, tables []DbTableMeta, foreignKeys []ForeignKey, namedParams bool) ([]*GeneratedStatement, error) {
+	primaryCnt := PrimaryKeyCount(root)
+
+	if primaryCnt == 0 {
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", root.TableName())
+	}
+
+	members := map[string]DbTableMeta{root.TableName(): root}
+	for _, dbTable := range tables {
+		members[dbTable.TableName()] = dbTable
+	}
+
+	// foreign keys between the tables given, along with the foreign keys referencing each table
+	var edges []ForeignKey
+	children := make(map[string][]ForeignKey)
+	for _, fk := range foreignKeys {
+		if members[fk.Table] != nil && members[fk.RefTable] != nil {
+			if len(fk.Columns) == 0 || len(fk.Columns) != len(fk.RefColumns) {
+				return nil, fmt.Errorf("foreign key %s from %s to %s has mismatched columns, cannot generate sql", fk.Name, fk.Table, fk.RefTable)
+			}
+			edges = append(edges, fk)
+			children[fk.RefTable] = append(children[fk.RefTable], fk)
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var order []string
+
+	var visit func(table string, path []string) error
+	visit = func(table string, path []string) error {
+		switch state[table] {
+		case visiting:
+			return fmt.Errorf("foreign keys form a cycle %s -> %s, cannot generate sql", strings.Join(path, " -> "), table)
+		case visited:
+			return nil
+		}
+
+		state[table] = visiting
+		for _, fk := range children[table] {
+			err := visit(fk.Table, append(path, table))
+			if err != nil {
+				return err
+			}
+		}
+		state[table] = visited
+		order = append(order, table)
+		return nil
+	}
+
+	err := visit(root.TableName(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(root)
+
+	buf := bytes.Buffer{}
+	var rootParams []StatementParam
+	pos := 1
+	for _, col := range root.Columns() {
+		if col.IsPrimaryKey() {
+			if pos > 1 {
+				buf.WriteString(" AND ")
+			}
+
+			param := d.Placeholder(pos)
+			if namedParams {
+				param = d.NamedPlaceholder(col.Name())
+			}
+			buf.WriteString(fmt.Sprintf("%s = %s", col.Name(), param))
+			rootParams = append(rootParams, newStatementParam(col.Name(), col))
+			pos++
+		}
+	}
+
+	// ? placeholders are not numbered so the root keys are bound for every occurrence of the root predicate
+	repeatParams := !namedParams && d.Placeholder(1) == d.Placeholder(2)
+
+	// predicate selecting the records of each table that depend on the root record, along with the number of paths to
+	// the root record it contains
+	predicates := map[string]string{root.TableName(): buf.String()}
+	paths := map[string]int{root.TableName(): 1}
+	var predicate func(table string) string
+	predicate = func(table string) string {
+		if p, ok := predicates[table]; ok {
+			return p
+		}
+
+		var parents []string
+		for _, fk := range edges {
+			if fk.Table != table || state[fk.RefTable] != visited {
+				continue
+			}
+
+			cols := strings.Join(fk.Columns, ", ")
+			if len(fk.Columns) > 1 {
+				cols = "(" + cols + ")"
+			}
+			parents = append(parents, fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)",
+				cols, strings.Join(fk.RefColumns, ", "), d.QuoteIdentifier(fk.RefTable), predicate(fk.RefTable)))
+			paths[table] += paths[fk.RefTable]
+		}
+
+		p := strings.Join(parents, " OR ")
+		predicates[table] = p
+		return p
+	}
+
+	statements := make([]*GeneratedStatement, len(order))
+	for i, table := range order {
+		sql := fmt.Sprintf("DELETE FROM %s WHERE %s", d.QuoteIdentifier(table), predicate(table))
+
+		params := rootParams
+		if repeatParams {
+			params = nil
+			for j := 0; j < paths[table]; j++ {
+				params = append(params, rootParams...)
+			}
+		}
+
+		statements[i] = &GeneratedStatement{
+			Name:   StatementName(members[table], "delete_cascade_"+root.TableName(), params),
+			SQL:    sql,
+			Params: params,
+		}
+	}
+	return statements, nil
+}
+
+// GenerateDeleteImpactSQL generate a SELECT COUNT(*) statement per foreign key of the graph referencing the table,
+// counting the child records that reference a record, so the dependents can be reported before the record is deleted.
+// Each statement binds the referenced columns of the record, in foreign key column order, and statements are returned
+// in the order of the foreign keys. Only the direct children are counted.
+func GenerateDeleteImpactSQL(dbTable DbTableMeta, fkGraph []ForeignKey) ([]*GeneratedStatement, error) {
+	d := DialectForTable(dbTable)
+
+	var statements []*GeneratedStatement
+	for _, fk := range fkGraph {
+		if fk.RefTable != dbTable.TableName() {
+			continue
+		}
+
+		if len(fk.Columns) == 0 || len(fk.Columns) != len(fk.RefColumns) {
+			return nil, fmt.Errorf("foreign key %s from %s to %s has mismatched columns, cannot generate sql", fk.Name, fk.Table, fk.RefTable)
+		}
+
+		var predicates []string
+		var params []StatementParam
+		for i, name := range fk.Columns {
+			refCol, ok := findColumn(dbTable, fk.RefColumns[i])
+			if !ok {
+				return nil, fmt.Errorf("table %s does not have a column %s referenced by foreign key %s, cannot generate sql", dbTable.TableName(), fk.RefColumns[i], fk.Name)
+			}
+
+			predicates = append(predicates, fmt.Sprintf("%s = %s", name, d.Placeholder(i+1)))
+			params = append(params, newStatementParam(refCol.Name(), refCol))
+		}
+
+		statements = append(statements, &GeneratedStatement{
+			Name:   StatementName(dbTable, "delete_impact_"+fk.Table, params),
+			SQL:    fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", d.QuoteIdentifier(fk.Table), strings.Join(predicates, " AND ")),
+			Params: params,
+		})
+	}
+	return statements, nil
+}
@@ -0,0 +1,154 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func Test_GenerateDeleteCascadeSQL(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ddl := []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users)`,
+		`CREATE TABLE order_items (id INTEGER PRIMARY KEY, order_id INTEGER REFERENCES orders(id))`,
+		`INSERT INTO users VALUES (1, 'a'), (2, 'b')`,
+		`INSERT INTO orders VALUES (10, 1), (20, 2)`,
+		`INSERT INTO order_items VALUES (100, 10), (200, 20)`,
+	}
+	for _, stmt := range ddl {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var foreignKeys []ForeignKey
+	for _, table := range []string{"orders", "order_items"} {
+		fks, err := LoadForeignKeys(db, "sqlite3", "main", table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		foreignKeys = append(foreignKeys, fks...)
+	}
+
+	if len(foreignKeys) != 2 || foreignKeys[0].RefColumns[0] != "id" {
+		t.Fatalf("unexpected foreign keys %v", foreignKeys)
+	}
+
+	users := testUsersTable()
+	users.sqlType = "sqlite3"
+	orders := &dbTableMeta{sqlType: "sqlite3", tableName: "orders"}
+	items := &dbTableMeta{sqlType: "sqlite3", tableName: "order_items"}
+
+	statements, err := GenerateDeleteCascadeSQL(users, []DbTableMeta{items, orders}, foreignKeys, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`DELETE FROM "order_items" WHERE order_id IN (SELECT id FROM "orders" WHERE user_id IN (SELECT id FROM "users" WHERE id = ?))`,
+		`DELETE FROM "orders" WHERE user_id IN (SELECT id FROM "users" WHERE id = ?)`,
+		`DELETE FROM "users" WHERE id = ?`,
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("expect %d statements, but got %d", len(expected), len(statements))
+	}
+
+	for i, stmt := range statements {
+		if stmt.SQL != expected[i] {
+			t.Errorf("expect: %s, but got %s", expected[i], stmt.SQL)
+		}
+
+		_, err = db.Exec(stmt.SQL, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", stmt.SQL, err)
+		}
+	}
+
+	var remaining int
+	err = db.QueryRow(`SELECT COUNT(*) FROM order_items`).Scan(&remaining)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 1 {
+		t.Errorf("expect: 1 remaining order item, but got %d", remaining)
+	}
+}
+
+func Test_GenerateDeleteCascadeSQLCycle(t *testing.T) {
+	users := testUsersTable()
+	teams := &dbTableMeta{sqlType: "postgres", tableName: "teams"}
+	foreignKeys := []ForeignKey{
+		{Name: "teams_owner", Table: "teams", Columns: []string{"owner_id"}, RefTable: "users", RefColumns: []string{"id"}},
+		{Name: "users_team", Table: "users", Columns: []string{"team_id"}, RefTable: "teams", RefColumns: []string{"id"}},
+	}
+
+	_, err := GenerateDeleteCascadeSQL(users, []DbTableMeta{teams}, foreignKeys, false)
+	if err == nil {
+		t.Errorf("expected error for foreign key cycle")
+	}
+}
+
+func Test_GenerateDeleteImpactSQL(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ddl := []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users)`,
+		`CREATE TABLE order_items (id INTEGER PRIMARY KEY, order_id INTEGER REFERENCES orders(id))`,
+		`INSERT INTO users VALUES (1, 'a'), (2, 'b')`,
+		`INSERT INTO orders VALUES (10, 1), (11, 1), (20, 2)`,
+	}
+	for _, stmt := range ddl {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var foreignKeys []ForeignKey
+	for _, table := range []string{"orders", "order_items"} {
+		fks, err := LoadForeignKeys(db, "sqlite3", "main", table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		foreignKeys = append(foreignKeys, fks...)
+	}
+
+	users := testUsersTable()
+	users.sqlType = "sqlite3"
+
+	statements, err := GenerateDeleteImpactSQL(users, foreignKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 1 {
+		t.Fatalf("expect 1 statement, but got %d", len(statements))
+	}
+
+	expected := `SELECT COUNT(*) FROM "orders" WHERE user_id = ?`
+	if statements[0].SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, statements[0].SQL)
+	}
+
+	var count int
+	err = db.QueryRow(statements[0].SQL, 1).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expect: 2 dependent orders, but got %d", count)
+	}
+}
@@ -0,0 +1,101 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Index secondary index of a table, the primary key index is not included
+type Index struct {
+	// Name name of the index
+	Name string
+
+	// Columns indexed columns, in index order
+	Columns []string
+
+	// Unique true if the index enforces unique values
+	Unique bool
+}
+
+// LoadIndexes fetch the secondary indexes of a table, supported for sqlite3, postgres and mysql databases. Expression
+// indexes are skipped as they cannot be described by their columns.
+func LoadIndexes(db *sql.DB, sqlType, sqlDatabase, tableName string) ([]Index, error) {
+	var query string
+	var args []interface{}
+
+	switch strings.ToLower(sqlType) {
+	case "sqlite3", "sqlite":
+		query = `
+SELECT il.name, il."unique", ii.name
+FROM pragma_index_list(?) il
+JOIN pragma_index_info(il.name) ii
+WHERE il.origin <> 'pk'
+ORDER BY il.name, ii.seqno`
+		args = []interface{}{tableName}
+	case "postgres":
+		query = `
+SELECT i.relname, ix.indisunique, a.attname
+FROM pg_class t
+JOIN pg_index ix ON ix.indrelid = t.oid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE t.relname = $1 AND NOT ix.indisprimary
+ORDER BY i.relname, k.ord`
+		args = []interface{}{tableName}
+	case "mysql":
+		query = `
+SELECT index_name, non_unique = 0, column_name
+FROM information_schema.statistics
+WHERE table_schema = ? AND table_name = ? AND index_name <> 'PRIMARY'
+ORDER BY index_name, seq_in_index`
+		args = []interface{}{sqlDatabase, tableName}
+	default:
+		return nil, fmt.Errorf("loading indexes is not supported for %s", sqlType)
+	}
+
+	res, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load indexes from %s: %v", tableName, err)
+	}
+	defer res.Close()
+
+	var indexes []Index
+	position := make(map[string]int)
+	expression := make(map[string]bool)
+	for res.Next() {
+		var name string
+		var unique bool
+		var column sql.NullString
+		err = res.Scan(&name, &unique, &column)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load indexes from %s Scan: %v", tableName, err)
+		}
+
+		if !column.Valid {
+			expression[name] = true
+			continue
+		}
+
+		i, ok := position[name]
+		if !ok {
+			i = len(indexes)
+			position[name] = i
+			indexes = append(indexes, Index{Name: name, Unique: unique})
+		}
+		indexes[i].Columns = append(indexes[i].Columns, column.String)
+	}
+	err = res.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Index, 0, len(indexes))
+	for _, idx := range indexes {
+		if !expression[idx.Name] {
+			result = append(result, idx)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// GenerateSelectJSONColumnSQL generate sql for selecting the json column of a record by primary key. When normalizeNull
+// is set a sql NULL is returned as a json null, so the value always scans into a json.RawMessage, otherwise a NULL
+// column is returned as is and can be told apart from a json null.
+func GenerateSelectJSONColumnSQL(dbTable DbTableMeta, column string, normalizeNull, namedParams bool, opts ...SQLOptions) (string, error) {
+	keys := primaryKeyColumns(dbTable)
+
+	if len(keys) == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	col, ok := findColumn(dbTable, column)
+	if !ok {
+		return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), column)
+	}
+	if !col.IsJSON() {
+		return "", fmt.Errorf("table %s column %s is not a json column, cannot generate sql", dbTable.TableName(), column)
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	err = opt.validatePredicates(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	err = opt.validateIndexHint(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	projection := opt.columnRef(column)
+	if normalizeNull {
+		null := "'null'"
+		switch d.Name() {
+		case "postgres":
+			null = "'null'::" + paramType(col)
+		case "mysql":
+			null = "CAST('null' AS JSON)"
+		}
+		projection = fmt.Sprintf("COALESCE(%s, %s) AS %s", projection, null, column)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE", projection, opt.tableRef(d, dbTable)))
+
+	where := newKeyWhere(d, opt, namedParams, 1)
+	for _, key := range keys {
+		where.addKey(key)
+	}
+	if tenantCol != nil {
+		where.addTenant(tenantCol)
+	}
+
+	buf.WriteString(" " + where.String())
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	return opt.routed(opt.annotated(dbTable, "select_json_column", opt.hinted(d, dbTable, buf.String()))), nil
+}
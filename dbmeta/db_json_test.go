@@ -0,0 +1,64 @@
+package dbmeta
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_GenerateSelectJSONColumnSQL(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("attrs", "JSONB", 3))
+
+	sql, err := GenerateSelectJSONColumnSQL(table, "attrs", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT COALESCE(attrs, 'null'::jsonb) AS attrs FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectJSONColumnSQL(table, "attrs", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT attrs FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectJSONColumnSQL(table, "name", true, false)
+	if err == nil {
+		t.Errorf("expect error for non json column")
+	}
+
+	stmt, err := GenerateSelectOneStatement(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stmt.JSONColumns, []string{"attrs"}) {
+		t.Errorf("expect: [attrs], but got %v", stmt.JSONColumns)
+	}
+}
+
+func Test_GenerateSelectJSONColumnSQLValidatesOptions(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("attrs", "JSONB", 3))
+
+	_, err := GenerateSelectJSONColumnSQL(table, "attrs", false, false, SQLOptions{TableAlias: "u; DROP TABLE users"})
+	if err == nil {
+		t.Errorf("expect error for invalid table alias")
+	}
+
+	_, err = GenerateSelectJSONColumnSQL(table, "attrs", false, false, SQLOptions{IndexHint: "missing_idx"})
+	if err == nil {
+		t.Errorf("expect error for index hint not on the table")
+	}
+
+	_, err = GenerateSelectJSONColumnSQL(table, "attrs", false, false, SQLOptions{Collations: map[string]string{"id": "C */ DROP"}})
+	if err == nil {
+		t.Errorf("expect error for invalid collation")
+	}
+}
@@ -0,0 +1,105 @@
+package dbmeta
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LintRule rule checked by LintStatement
+type LintRule string
+
+const (
+	// LintSelectStar the statement selects every column with SELECT *
+	LintSelectStar LintRule = "select-star"
+
+	// LintMissingWhere an UPDATE or DELETE does not have a WHERE clause and changes every record
+	LintMissingWhere LintRule = "missing-where"
+
+	// LintPlaceholderSequence the positional placeholders do not run sequentially from 1
+	LintPlaceholderSequence LintRule = "placeholder-sequence"
+)
+
+// LintWarning problem found in a sql statement
+type LintWarning struct {
+	// Rule rule that raised the warning
+	Rule LintRule
+
+	// Message description of the problem
+	Message string
+
+	// Offset byte offset in the statement where the problem was found, -1 if it applies to the whole statement
+	Offset int
+}
+
+// String describe the warning
+func (w LintWarning) String() string {
+	if w.Offset < 0 {
+		return fmt.Sprintf("%s: %s", w.Rule, w.Message)
+	}
+	return fmt.Sprintf("%s: %s at offset %d", w.Rule, w.Message, w.Offset)
+}
+
+var (
+	lintStringLiteralRegex = regexp.MustCompile(`'(?:[^']|'')*'`)
+	lintSelectStarRegex    = regexp.MustCompile(`(?i)\bSELECT\s+(?:DISTINCT\s+|TOP\s*\(?[^)\s]+\)?\s+)?(?:\w+\.)?\*`)
+	lintMutationRegex      = regexp.MustCompile(`(?i)^\s*(UPDATE|DELETE)\b`)
+	lintWhereRegex         = regexp.MustCompile(`(?i)\bWHERE\b`)
+	lintPlaceholderRegex   = regexp.MustCompile(`(?:\$|@p)(\d+)\b`)
+)
+
+// LintStatement check a sql statement for SELECT *, UPDATE or DELETE without a WHERE clause and positional placeholders
+// ($1 or @p1) that are not numbered sequentially from 1. String literals are ignored.
+func LintStatement(sql string) []LintWarning {
+	// blank out string literals, keeping the offsets intact
+	stripped := lintStringLiteralRegex.ReplaceAllStringFunc(sql, func(s string) string {
+		return strings.Repeat(" ", len(s))
+	})
+
+	var warnings []LintWarning
+	for _, loc := range lintSelectStarRegex.FindAllStringIndex(stripped, -1) {
+		warnings = append(warnings, LintWarning{
+			Rule:    LintSelectStar,
+			Message: "SELECT * depends on the table column order, list the columns explicitly",
+			Offset:  loc[0],
+		})
+	}
+
+	if match := lintMutationRegex.FindStringSubmatch(stripped); match != nil && !lintWhereRegex.MatchString(stripped) {
+		warnings = append(warnings, LintWarning{
+			Rule:    LintMissingWhere,
+			Message: fmt.Sprintf("%s without a WHERE clause changes every record", strings.ToUpper(match[1])),
+			Offset:  -1,
+		})
+	}
+
+	seen := make(map[int]bool)
+	var positions []int
+	for _, match := range lintPlaceholderRegex.FindAllStringSubmatch(stripped, -1) {
+		pos, err := strconv.Atoi(match[1])
+		if err != nil || seen[pos] {
+			continue
+		}
+		seen[pos] = true
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+
+	var missing []string
+	for i := 1; len(positions) > 0 && i <= positions[len(positions)-1]; i++ {
+		if !seen[i] {
+			missing = append(missing, strconv.Itoa(i))
+		}
+	}
+	if len(missing) > 0 {
+		warnings = append(warnings, LintWarning{
+			Rule:    LintPlaceholderSequence,
+			Message: fmt.Sprintf("placeholders are not sequential, missing %s", strings.Join(missing, ", ")),
+			Offset:  -1,
+		})
+	}
+
+	return warnings
+}
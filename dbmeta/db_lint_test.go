@@ -0,0 +1,57 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_LintStatement(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected []LintRule
+	}{
+		{`SELECT id, name FROM "users" WHERE id = $1`, nil},
+		{`SELECT * FROM "users" WHERE id = $1`, []LintRule{LintSelectStar}},
+		{`SELECT u.* FROM "users" u`, []LintRule{LintSelectStar}},
+		{`SELECT COUNT(*) FROM "users" WHERE name = 'SELECT *'`, nil},
+		{`DELETE FROM "users"`, []LintRule{LintMissingWhere}},
+		{`UPDATE "users" SET name = $1 WHERE id = $3`, []LintRule{LintPlaceholderSequence}},
+	}
+
+	for _, tt := range tests {
+		warnings := LintStatement(tt.sql)
+		if len(warnings) != len(tt.expected) {
+			t.Errorf("%s expect: %v, but got %v", tt.sql, tt.expected, warnings)
+			continue
+		}
+		for i, w := range warnings {
+			if w.Rule != tt.expected[i] {
+				t.Errorf("%s expect: %s, but got %s", tt.sql, tt.expected[i], w)
+			}
+		}
+	}
+}
+
+func Test_LintGeneratedStatements(t *testing.T) {
+	table := testUsersTable()
+	opt := SQLOptions{Include: func(col ColumnMeta) bool { return true }}
+
+	generators := map[string]func() (string, error){
+		"insert":      func() (string, error) { return GenerateInsertSQL(table, false, opt) },
+		"update":      func() (string, error) { return GenerateUpdateSQL(table, false, opt) },
+		"hard delete": func() (string, error) { return GenerateHardDeleteSQL(table, false) },
+		"select one":  func() (string, error) { return GenerateSelectOneSQL(table, false, opt) },
+		"page":        func() (string, error) { return GenerateSelectPageSQL(table, false, opt) },
+		"cursor page": func() (string, error) { return GenerateCursorPageSQL(table, Forward, false, opt) },
+		"upsert":      func() (string, error) { return GenerateUpsertSQL(table, false, opt) },
+	}
+
+	for name, generate := range generators {
+		sql, err := generate()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if warnings := LintStatement(sql); len(warnings) > 0 {
+			t.Errorf("%s: %s has lint warnings %v", name, sql, warnings)
+		}
+	}
+}
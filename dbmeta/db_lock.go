@@ -0,0 +1,124 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LockMode row lock taken by a select statement
+type LockMode int
+
+const (
+	// LockNone do not lock the selected records
+	LockNone LockMode = iota
+	// LockForUpdate lock the selected records for update
+	LockForUpdate
+	// LockForShare lock the selected records with a shared lock
+	LockForShare
+	// LockForNoKeyUpdate lock the selected records for an update that does not change the keys (Postgres only)
+	LockForNoKeyUpdate
+	// LockForKeyShare lock the selected records against changes to the keys (Postgres only)
+	LockForKeyShare
+)
+
+// String describe the lock mode
+func (mode LockMode) String() string {
+	switch mode {
+	case LockNone:
+		return "None"
+	case LockForUpdate:
+		return "ForUpdate"
+	case LockForShare:
+		return "ForShare"
+	case LockForNoKeyUpdate:
+		return "ForNoKeyUpdate"
+	case LockForKeyShare:
+		return "ForKeyShare"
+	default:
+		return fmt.Sprintf("unknown lock mode: %d", int(mode))
+	}
+}
+
+// lockClause clause appended to a select statement to take the SQLOptions.Lock, followed by NOWAIT or SKIP LOCKED when
+// set, empty for LockNone
+func lockClause(d Dialect, opt SQLOptions) (string, error) {
+	if opt.NoWait && opt.SkipLocked {
+		return "", fmt.Errorf("lock options NOWAIT and SKIP LOCKED are mutually exclusive, cannot generate sql")
+	}
+
+	mode := opt.Lock
+	if mode == LockNone {
+		if opt.NoWait || opt.SkipLocked {
+			return "", fmt.Errorf("lock options NOWAIT and SKIP LOCKED require a lock mode, cannot generate sql")
+		}
+		return "", nil
+	}
+
+	clause := ""
+	switch d.Name() {
+	case "postgres":
+		switch mode {
+		case LockForUpdate:
+			clause = " FOR UPDATE"
+		case LockForShare:
+			clause = " FOR SHARE"
+		case LockForNoKeyUpdate:
+			clause = " FOR NO KEY UPDATE"
+		case LockForKeyShare:
+			clause = " FOR KEY SHARE"
+		}
+	case "mysql":
+		switch mode {
+		case LockForUpdate:
+			clause = " FOR UPDATE"
+		case LockForShare:
+			clause = " LOCK IN SHARE MODE"
+			// LOCK IN SHARE MODE does not take NOWAIT or SKIP LOCKED, the MySQL 8 FOR SHARE does
+			if opt.NoWait || opt.SkipLocked {
+				clause = " FOR SHARE"
+			}
+		}
+	}
+	if clause == "" {
+		return "", fmt.Errorf("lock mode %s is not supported by dialect %s, cannot generate sql", mode, d.Name())
+	}
+
+	switch {
+	case opt.NoWait:
+		clause += " NOWAIT"
+	case opt.SkipLocked:
+		clause += " SKIP LOCKED"
+	}
+	return clause, nil
+}
+
+// GeneratePgAdvisoryLockSQL generate sql taking a transaction scoped advisory lock keyed on the primary key of a record,
+// so workers can serialize the processing of a record without locking its row. The primary keys are bound in order and
+// hashed as text, composite keys are joined with ':'. The lock is released when the transaction ends, only Postgres
+// supports advisory locks.
+func GeneratePgAdvisoryLockSQL(dbTable DbTableMeta) (string, error) {
+	primaryCnt := PrimaryKeyCount(dbTable)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	d := DialectForTable(dbTable)
+	if d.Name() != "postgres" {
+		return "", fmt.Errorf("table %s dialect %s does not support advisory locks, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	var keys []string
+	pos := 1
+	for _, col := range dbTable.Columns() {
+		if col.IsPrimaryKey() {
+			param := d.Placeholder(pos)
+			if primaryCnt > 1 || !isTextColumn(col) {
+				param += "::text"
+			}
+			keys = append(keys, param)
+			pos++
+		}
+	}
+	return fmt.Sprintf("SELECT pg_advisory_xact_lock(hashtext(%s))", strings.Join(keys, " || ':' || ")), nil
+}
@@ -0,0 +1,71 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GeneratePgAdvisoryLockSQL(t *testing.T) {
+	sql, err := GeneratePgAdvisoryLockSQL(testUsersTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT pg_advisory_xact_lock(hashtext($1::text))`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	composite := testUsersTable()
+	composite.columns[1].isPrimaryKey = true
+	sql, err = GeneratePgAdvisoryLockSQL(composite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT pg_advisory_xact_lock(hashtext($1::text || ':' || $2::text))`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	mysqlUsers := testUsersTable()
+	mysqlUsers.sqlType = "mysql"
+	_, err = GeneratePgAdvisoryLockSQL(mysqlUsers)
+	if err == nil {
+		t.Errorf("expect error for mysql advisory lock")
+	}
+}
+
+func Test_GenerateSelectOneSQLNoWait(t *testing.T) {
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Lock: LockForUpdate, NoWait: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id = $1 FOR UPDATE NOWAIT`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	mysqlUsers := testUsersTable()
+	mysqlUsers.sqlType = "mysql"
+	sql, err = GenerateSelectOneSQL(mysqlUsers, false, SQLOptions{Lock: LockForShare, SkipLocked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT * FROM `users` WHERE id = ? FOR SHARE SKIP LOCKED"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []SQLOptions{
+		{Lock: LockForUpdate, NoWait: true, SkipLocked: true},
+		{NoWait: true},
+	}
+	for _, opt := range invalid {
+		_, err = GenerateSelectOneSQL(testUsersTable(), false, opt)
+		if err == nil {
+			t.Errorf("expect error for lock options %v", opt)
+		}
+	}
+}
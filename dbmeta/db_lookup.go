@@ -0,0 +1,94 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// lookupAlias alias of the referenced table joined by GenerateSelectWithLookupSQL
+const lookupAlias = "ref"
+
+// GenerateSelectWithLookupSQL generate sql for selecting a record by primary key along with the display column of the
+// record referenced by the foreign key column, projected as <fkColumn>_label. The referenced table is LEFT JOINed so
+// records without a reference are still returned with a NULL label. The foreign key is taken from the table
+// ForeignKeys and the referenced table must be the one it references, so the display column can be validated.
+func GenerateSelectWithLookupSQL(dbTable, refTable DbTableMeta, fkColumn, displayColumn string, namedParams bool, opts ...SQLOptions) (string, error) {
+	primaryCnt := PrimaryKeyCount(dbTable)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	var fk *ForeignKey
+	for i, candidate := range dbTable.ForeignKeys() {
+		if _, ok := FindInSlice(candidate.Columns, fkColumn); ok {
+			fk = &dbTable.ForeignKeys()[i]
+			break
+		}
+	}
+	if fk == nil {
+		return "", fmt.Errorf("table %s does not have a foreign key on column %s, cannot generate sql", dbTable.TableName(), fkColumn)
+	}
+
+	if fk.RefTable != refTable.TableName() {
+		return "", fmt.Errorf("foreign key %s references %s not %s, cannot generate sql", fk.Name, fk.RefTable, refTable.TableName())
+	}
+
+	if _, ok := findColumn(refTable, displayColumn); !ok {
+		return "", fmt.Errorf("table %s does not have a display column %s, cannot generate sql", refTable.TableName(), displayColumn)
+	}
+
+	opt := assureSQLOptions(opts...)
+	if opt.TableAlias == "" {
+		opt.TableAlias = "t"
+	}
+	if opt.TableAlias == lookupAlias {
+		return "", fmt.Errorf("table alias %s is used by the lookup table, cannot generate sql", lookupAlias)
+	}
+
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	var on []string
+	for i, col := range fk.Columns {
+		on = append(on, fmt.Sprintf("%s.%s = %s", lookupAlias, fk.RefColumns[i], opt.columnRef(col)))
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s, %s.%s AS %s_label FROM %s LEFT JOIN %s %s ON %s WHERE",
+		projection, lookupAlias, displayColumn, fkColumn, opt.tableRef(d, dbTable),
+		d.QuoteIdentifier(refTable.TableName()), lookupAlias, strings.Join(on, " AND ")))
+
+	where := newKeyWhere(d, opt, namedParams, 1)
+	for _, col := range dbTable.Columns() {
+		if col.IsPrimaryKey() {
+			where.addKey(col)
+		}
+	}
+	if tenantCol != nil {
+		where.addTenant(tenantCol)
+	}
+
+	buf.WriteString(" " + where.String())
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	return opt.routed(opt.annotated(dbTable, "select_with_lookup", opt.hinted(d, dbTable, buf.String()))), nil
+}
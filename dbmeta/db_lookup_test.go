@@ -0,0 +1,48 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateSelectWithLookupSQL(t *testing.T) {
+	users := testUsersTable()
+	orders := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "orders",
+		columns: []*columnMeta{
+			testColumn("id", "INT4", 0),
+			testColumn("user_id", "INT4", 1),
+		},
+		foreignKeys: []ForeignKey{
+			{Name: "orders_user", Table: "orders", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}},
+		},
+	}
+	orders.columns[0].isPrimaryKey = true
+
+	sql, err := GenerateSelectWithLookupSQL(orders, users, "user_id", "name", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT t.*, ref.name AS user_id_label FROM "orders" t LEFT JOIN "users" ref ON ref.id = t.user_id WHERE t.id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []struct {
+		name          string
+		refTable      DbTableMeta
+		fkColumn      string
+		displayColumn string
+	}{
+		{"no foreign key", users, "id", "name"},
+		{"wrong referenced table", orders, "user_id", "id"},
+		{"unknown display column", users, "user_id", "missing"},
+	}
+	for _, tt := range invalid {
+		_, err = GenerateSelectWithLookupSQL(orders, tt.refTable, tt.fkColumn, tt.displayColumn, false)
+		if err == nil {
+			t.Errorf("%s: expect error", tt.name)
+		}
+	}
+}
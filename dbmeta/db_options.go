@@ -0,0 +1,717 @@
+package dbmeta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// predicateOperand marker of the operand in SQLOptions.PredicateExpressions
+const predicateOperand = "?"
+
+var safeIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var routingCommentRegex = regexp.MustCompile(`^[A-Za-z0-9_ :=.,-]+$`)
+
+var collationRegex = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+var commentValueRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// ComputedColumn expression selected along with the table columns, e.g. date_part('year', now()) - year_of_birth AS age
+type ComputedColumn struct {
+	// Expr sql expression, used as is
+	Expr string
+
+	// Alias name of the expression in the result
+	Alias string
+}
+
+// SQLOptions specifies optional settings when generating sql.
+type SQLOptions struct {
+	// TableAlias alias for the table in select statements, column references are prefixed with the alias
+	// e.g. SELECT t.* FROM "users" t WHERE t.id = $1
+	TableAlias string
+
+	// Include filter the columns used by the insert, update and select generators, return false to skip a column.
+	// Primary keys used in where clauses are not filtered. If nil all eligible columns are included.
+	Include func(col ColumnMeta) bool
+
+	// UpsertUpdateColumns columns set by the conflict update clause of an upsert, if empty all non primary key
+	// columns are updated
+	UpsertUpdateColumns []string
+
+	// UpsertConflict how an upsert infers its conflict target, see InferConflictTarget. Conflicts on the primary key by
+	// default.
+	UpsertConflict ConflictMode
+
+	// UpsertInsertOnlyColumns columns an upsert sets when inserting but never overwrites on conflict, e.g. created_at
+	UpsertInsertOnlyColumns []string
+
+	// UpsertUpdateWhere guard predicate of the conflict update clause of a postgres or sqlite upsert, e.g.
+	// EXCLUDED.updated_at > "users".updated_at, the existing record is only updated when the predicate holds. Column
+	// references must be qualified with EXCLUDED or the table name.
+	UpsertUpdateWhere string
+
+	// IncludeDeleted include soft deleted records when selecting records by primary key, if false and the table has
+	// a soft delete column the records are filtered with deleted_at IS NULL
+	IncludeDeleted bool
+
+	// LegacyPaging page with SELECT TOP and ROW_NUMBER() on SQL Server versions before 2012, which do not support
+	// OFFSET ... FETCH NEXT
+	LegacyPaging bool
+
+	// CaseInsensitiveKeys match text primary keys case-insensitively with LOWER(col) = LOWER($1) when selecting a record
+	// by primary key, other key columns are matched with plain equality
+	CaseInsensitiveKeys bool
+
+	// PredicateExpressions expressions wrapping both sides of the predicate matching the named columns, with ? marking
+	// the operand, so the where clause matches a functional index, e.g. {"email": "LOWER(?)"} matches with
+	// LOWER(email) = LOWER($1). Takes precedence over CaseInsensitiveKeys.
+	PredicateExpressions map[string]string
+
+	// Collations collations the predicates matching the named text columns compare with, e.g. {"name": "en_US"} matches
+	// with name COLLATE "en_US" = $1, for case or accent insensitive lookups driven by the collation
+	Collations map[string]string
+
+	// BoolPredicates boolean columns the records selected by primary key or by columns must match, compared with the
+	// boolean literal of the dialect, e.g. {"active": true} matches with active = TRUE on Postgres and active = 1 on SQL
+	// Server. The columns must be boolean typed.
+	BoolPredicates map[string]bool
+
+	// JSONMergeColumns json or jsonb columns merged with the parameter by GenerateUpdateSQL instead of being replaced,
+	// e.g. SET attrs = attrs || $1::jsonb, only supported by Postgres
+	JSONMergeColumns []string
+
+	// Lock row lock taken by GenerateSelectOneSQL, defaults to LockNone
+	Lock LockMode
+
+	// NoWait fail the select immediately with NOWAIT instead of waiting when a record is locked, requires a Lock
+	NoWait bool
+
+	// SkipLocked leave out the locked records with SKIP LOCKED instead of waiting, requires a Lock and cannot be
+	// combined with NoWait
+	SkipLocked bool
+
+	// Columns columns selected by the select generators in the given order instead of *, e.g. from
+	// SelectColumnsFromStruct. Every column must exist in the table.
+	Columns []string
+
+	// IndexHint index the select generators force the planner to use, emitted after the table reference as
+	// USE INDEX (idx) on MySQL, WITH (INDEX(idx)) on SQL Server, INDEXED BY idx on Sqlite and as a leading pg_hint_plan
+	// IndexScan comment on Postgres. Must be one of the table indexes, if empty no hint is emitted.
+	IndexHint string
+
+	// Only select only the records of the table itself and not of the tables inheriting from it with FROM ONLY, only
+	// applies to Postgres and is ignored by other dialects
+	Only bool
+
+	// Partition name of the partition the select generators read from in place of the table, e.g. events_2024_01, to
+	// skip partition pruning. The table columns are used for the predicates and projection.
+	Partition string
+
+	// StatementIDComment prefix the statement with a /* id:xxxx */ comment holding the StatementID of its sql, so slow
+	// query logs can be correlated with the generated statement. Applied by every generator taking SQLOptions, inside
+	// the OperationComment. Cannot be combined with a Postgres IndexHint, which must be the first comment of the
+	// statement.
+	StatementIDComment bool
+
+	// OperationComment prefix the statement with a /* gen table=users op=select_one */ comment naming the table and
+	// operation, so query logs group the statements of a table. Applied by every generator taking SQLOptions, after
+	// the RoutingComment which always leads the statement. Generators without SQLOptions, e.g. GenerateUpdateChangedSQL,
+	// GenerateDeleteWithAuditSQL and the tree generators, are never annotated.
+	OperationComment bool
+
+	// RoutingComment comment prepended by every select generator taking SQLOptions, including the page, cursor page and
+	// key range selects and the declared cursors, e.g. replica for /* replica */ SELECT ..., so a proxy routes the reads
+	// to a replica. The insert, update, upsert and delete generators refuse the option. Limited to letters, digits,
+	// spaces and _ : = . , -
+	RoutingComment string
+
+	// TimeZone convert the time zone aware timestamp columns of an explicit projection to the time zone bound to the
+	// first parameter of GenerateSelectOneSQL: col AT TIME ZONE $1 on Postgres timestamptz and SQL Server datetimeoffset
+	// columns, CONVERT_TZ(col, @@session.time_zone, ?) on MySQL timestamp columns. Other columns are left as is.
+	TimeZone bool
+
+	// CastParams cast the positional parameters of the select, update and delete generators to the column type,
+	// e.g. WHERE id = $1::uuid, for drivers that cannot infer the parameter type. Only supported by Postgres.
+	CastParams bool
+
+	// IDCount number of ids selected by GenerateSelectMultiSQL on dialects that select by an IN list of placeholders
+	// rather than an array
+	IDCount int
+
+	// Computed expressions appended to the columns selected by the select generators
+	Computed []ComputedColumn
+
+	// AsOf select the record of a system versioned table as of the point in time bound to the first parameter with
+	// FOR SYSTEM_TIME AS OF, supported by SQL Server and MariaDB
+	AsOf bool
+
+	// Returning columns returned by the rows a statement writes, * returns every column. Postgres and Sqlite use
+	// RETURNING, SQL Server uses OUTPUT, MySQL does not support returning the written rows.
+	Returning []string
+
+	// Sequences sequences the named primary keys are populated from by an insert with nextval('seq') instead of a
+	// parameter, for keys driven by a named sequence that are not auto increment. Only supported by Postgres.
+	Sequences map[string]string
+
+	// ReturnGenerated return every column an insert does not supply when Returning is not set, so the values populated
+	// by the database are read back
+	ReturnGenerated bool
+
+	// OrderBy columns the page and select all generators order by, the primary keys not listed are appended so the
+	// order is stable. If empty pages are ordered by the primary keys and select all is not ordered.
+	OrderBy []OrderColumn
+
+	// Unbounded opt out of the DefaultMaxRows cap of GenerateSelectAllSQL, e.g. for exports that must read every record
+	Unbounded bool
+
+	// TenantColumn column holding the tenant of a record, e.g. tenant_id. When set the records read by every select
+	// generator taking SQLOptions are also matched on the tenant, bound after the keys and before the page size, and the
+	// table must have the column. Predicates are always ordered keys, tenant, boolean predicates then the soft delete
+	// filter, so the parameter bindings are stable. The insert, update, upsert and delete generators refuse TenantColumn
+	// and DetectTenant rather than write the records of every tenant.
+	TenantColumn string
+
+	// DetectTenant match the records on the tenant_id or TenantID column when TenantColumn is not set, if the table has
+	// one. Tables without a tenant column are not tenant scoped.
+	DetectTenant bool
+
+	// ErrorOnEmptyInsert return ErrNoInsertableColumns from an insert of a table without insertable columns instead of
+	// inserting a record of default values
+	ErrorOnEmptyInsert bool
+
+	// ColumnOrder order of the columns of an insert, e.g. to match a COPY file. Must list every insertable column
+	// exactly once, if empty the columns are inserted in table order.
+	ColumnOrder []string
+
+	// CoalesceNulls wrap the nullable columns of an explicit projection in COALESCE so they are never NULL, using
+	// CoalesceDefaults or the zero value of the column type: '' for text, 0 for numbers and false for booleans. Not
+	// supported for * projections.
+	CoalesceNulls bool
+
+	// CoalesceDefaults sql literal replacing NULL values of the named nullable columns when CoalesceNulls is set
+	CoalesceDefaults map[string]string
+
+	// SoftDeleteColumn name of the timestamp column flagging a record as soft deleted, if empty deleted_at or DeletedAt
+	SoftDeleteColumn string
+
+	// DeletedByColumn name of the column a soft delete records the deleting user in when the table has it, if empty
+	// deleted_by
+	DeletedByColumn string
+
+	// schemaTemplate qualify the table with the SchemaPlaceholder, set by the template generators
+	schemaTemplate bool
+
+	// timeZoneParam placeholder of the time zone the TimeZone columns are converted to, set by the generators
+	// supporting TimeZone
+	timeZoneParam string
+}
+
+// DefaultSQLOptions provides default options,
+// which would be modified by usage-side.
+func DefaultSQLOptions() SQLOptions {
+	return SQLOptions{}
+}
+
+// assureSQLOptions returns the SQLOptions to use, should be called only once.
+func assureSQLOptions(opts ...SQLOptions) SQLOptions {
+	if len(opts) == 0 {
+		return DefaultSQLOptions()
+	}
+	return opts[0]
+}
+
+// validate check the options are safe to use in a generated statement
+func (opt SQLOptions) validate() error {
+	if opt.TableAlias != "" {
+		err := validateIdentifier("table alias", opt.TableAlias)
+		if err != nil {
+			return err
+		}
+	}
+	if opt.RoutingComment != "" && !routingCommentRegex.MatchString(opt.RoutingComment) {
+		return fmt.Errorf("invalid routing comment %q, must contain only letters, digits, spaces and _ : = . , -", opt.RoutingComment)
+	}
+	if opt.Partition != "" {
+		err := validateIdentifier("partition", opt.Partition)
+		if err != nil {
+			return err
+		}
+	}
+	for name, expr := range opt.PredicateExpressions {
+		if !strings.Contains(expr, predicateOperand) {
+			return fmt.Errorf("predicate expression %s of column %s does not reference the %s operand, cannot generate sql", expr, name, predicateOperand)
+		}
+	}
+	for name, collation := range opt.Collations {
+		if !collationRegex.MatchString(collation) {
+			return fmt.Errorf("collation %s of column %s is not a valid collation name, cannot generate sql", collation, name)
+		}
+	}
+	return nil
+}
+
+// validateDialect check the options are supported by the dialect
+func (opt SQLOptions) validateDialect(d Dialect) error {
+	if opt.CastParams && d.Name() != "postgres" {
+		return fmt.Errorf("dialect %s does not support parameter casts, cannot generate sql", d.Name())
+	}
+	if (opt.StatementIDComment || opt.OperationComment || opt.RoutingComment != "") && opt.IndexHint != "" && d.Name() == "postgres" {
+		return fmt.Errorf("dialect %s index hints must be the first comment of the statement, cannot generate sql with a leading comment", d.Name())
+	}
+	return nil
+}
+
+// validateMutation check the options can be used by a statement writing records, read only options are refused
+func (opt SQLOptions) validateMutation() error {
+	if opt.RoutingComment != "" {
+		return fmt.Errorf("routing comment %q is only supported for reads, cannot generate sql", opt.RoutingComment)
+	}
+	if opt.TenantColumn != "" || opt.DetectTenant {
+		return fmt.Errorf("tenant scoping is only supported for reads, the mutation would not be matched on the tenant, cannot generate sql")
+	}
+	return nil
+}
+
+// routed prefix the sql of a select with the RoutingComment if set
+func (opt SQLOptions) routed(sql string) string {
+	if opt.RoutingComment == "" {
+		return sql
+	}
+	return fmt.Sprintf("/* %s */ %s", opt.RoutingComment, sql)
+}
+
+// annotated prefix the sql with its StatementID comment when StatementIDComment is set and with the OperationComment of
+// the table and operation if set. The comments are the same for every execution of the statement so prepared
+// statement caches keyed by the sql are not fragmented, characters that could close the operation comment are replaced
+// so table names cannot inject sql.
+func (opt SQLOptions) annotated(dbTable DbTableMeta, operation, sql string) string {
+	if opt.StatementIDComment {
+		sql = withStatementID(sql)
+	}
+	if !opt.OperationComment {
+		return sql
+	}
+	return fmt.Sprintf("/* gen table=%s op=%s */ %s", commentValueRegex.ReplaceAllString(dbTable.TableName(), "_"),
+		commentValueRegex.ReplaceAllString(operation, "_"), sql)
+}
+
+// returningColumns validate the returning columns and return them in the form of the dialect, prefixed with the
+// pseudo table for SQL Server OUTPUT clauses. An empty slice is returned if no columns are returned.
+func (opt SQLOptions) returningColumns(d Dialect, dbTable DbTableMeta, pseudoTable string) ([]string, error) {
+	if len(opt.Returning) == 0 {
+		return nil, nil
+	}
+
+	if d.Name() == "mysql" {
+		return nil, fmt.Errorf("table %s dialect %s does not support returning the written rows, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	cols := make([]string, len(opt.Returning))
+	for i, name := range opt.Returning {
+		if name != "*" {
+			if _, ok := findColumn(dbTable, name); !ok {
+				return nil, fmt.Errorf("table %s does not have a returning column %s, cannot generate sql", dbTable.TableName(), name)
+			}
+		}
+
+		cols[i] = name
+		if d.Name() == "mssql" {
+			cols[i] = pseudoTable + "." + name
+		}
+	}
+	return cols, nil
+}
+
+// returnedColumns names of the columns returned by a statement in result order, * is expanded to every column of the
+// table
+func returnedColumns(dbTable DbTableMeta, returning []string) []string {
+	var columns []string
+	for _, name := range returning {
+		if name == "*" {
+			for _, col := range dbTable.Columns() {
+				columns = append(columns, col.Name())
+			}
+			continue
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// tenantColumnNames names of the tenant columns detected when SQLOptions.DetectTenant is set
+var tenantColumnNames = []string{"tenant_id", "TenantID"}
+
+// tenantColumn lookup the tenant column of the table, nil if the records are not tenant scoped
+func (opt SQLOptions) tenantColumn(dbTable DbTableMeta) (ColumnMeta, error) {
+	if opt.TenantColumn == "" {
+		if opt.DetectTenant {
+			for _, name := range tenantColumnNames {
+				if col, ok := findColumn(dbTable, name); ok {
+					return col, nil
+				}
+			}
+		}
+		return nil, nil
+	}
+
+	col, ok := findColumn(dbTable, opt.TenantColumn)
+	if !ok {
+		return nil, fmt.Errorf("table %s does not have a tenant column %s, cannot generate sql", dbTable.TableName(), opt.TenantColumn)
+	}
+	return col, nil
+}
+
+// castParam cast a positional parameter to the column type if CastParams is set
+func (opt SQLOptions) castParam(col ColumnMeta, param string) string {
+	if opt.CastParams {
+		return param + "::" + paramType(col)
+	}
+	return param
+}
+
+// columnRef reference to a column, prefixed with the table alias if set
+func (opt SQLOptions) columnRef(name string) string {
+	if opt.TableAlias != "" {
+		return opt.TableAlias + "." + name
+	}
+	return name
+}
+
+// includeColumn return true if the column passes the Include filter
+func (opt SQLOptions) includeColumn(col ColumnMeta) bool {
+	return opt.Include == nil || opt.Include(col)
+}
+
+// projection columns selected by a select statement, * is used unless the Columns or an Include filter are set, followed
+// by the computed columns
+func (opt SQLOptions) projection(dbTable DbTableMeta) (string, error) {
+	err := opt.validateIndexHint(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	cols, err := opt.columnsProjection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	aliases := make(map[string]bool)
+	for _, computed := range opt.Computed {
+		if strings.TrimSpace(computed.Expr) == "" {
+			return "", fmt.Errorf("computed column %s does not have an expression, cannot generate sql", computed.Alias)
+		}
+
+		err = validateIdentifier("computed column alias", computed.Alias)
+		if err != nil {
+			return "", err
+		}
+
+		alias := strings.ToLower(computed.Alias)
+		for _, col := range dbTable.Columns() {
+			if strings.ToLower(col.Name()) == alias {
+				return "", fmt.Errorf("table %s already has a column %s, computed column alias must be unique", dbTable.TableName(), computed.Alias)
+			}
+		}
+		if aliases[alias] {
+			return "", fmt.Errorf("computed column alias %s is used more than once, cannot generate sql", computed.Alias)
+		}
+		aliases[alias] = true
+
+		cols = fmt.Sprintf("%s, %s AS %s", cols, computed.Expr, computed.Alias)
+	}
+	return cols, nil
+}
+
+// ProjectedColumns return the names of the columns selected by the select generators for the options, in projection
+// order, so scan targets can be generated in the same order. A * projection is expanded to the table columns in table
+// order, computed columns follow by their alias.
+func ProjectedColumns(dbTable DbTableMeta, opts ...SQLOptions) ([]string, error) {
+	opt := assureSQLOptions(opts...)
+	if opt.TimeZone && opt.timeZoneParam == "" {
+		// only the names are needed, the placeholder is not used
+		opt.timeZoneParam = "?"
+	}
+
+	_, err := opt.projection(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if len(opt.Columns) > 0 {
+		names = append(names, opt.Columns...)
+	} else {
+		for _, col := range dbTable.Columns() {
+			if opt.Include == nil || opt.includeColumn(col) {
+				names = append(names, col.Name())
+			}
+		}
+	}
+
+	for _, computed := range opt.Computed {
+		names = append(names, computed.Alias)
+	}
+	return names, nil
+}
+
+// projectColumn reference to a projected column, converted to the time zone when TimeZone is set and wrapped in
+// COALESCE when CoalesceNulls is set and the column is nullable
+func (opt SQLOptions) projectColumn(dbTable DbTableMeta, col ColumnMeta) (string, error) {
+	ref := opt.columnRef(col.Name())
+	if opt.TimeZone {
+		if opt.timeZoneParam == "" {
+			return "", fmt.Errorf("table %s time zone conversion is only supported when selecting one record, cannot generate sql", dbTable.TableName())
+		}
+
+		d := DialectForTable(dbTable)
+		if isTimeZoneColumn(d, col) {
+			ref = timeZoneExpr(d, ref, opt.timeZoneParam)
+		}
+	}
+
+	if !opt.CoalesceNulls || !col.Nullable() {
+		if ref != opt.columnRef(col.Name()) {
+			return fmt.Sprintf("%s AS %s", ref, col.Name()), nil
+		}
+		return ref, nil
+	}
+
+	value, ok := opt.CoalesceDefaults[col.Name()]
+	if !ok {
+		value, ok = coalesceDefault(col)
+		if !ok {
+			return "", fmt.Errorf("table %s column %s type %s does not have a coalesce default, cannot generate sql",
+				dbTable.TableName(), col.Name(), col.DatabaseTypeName())
+		}
+	}
+	return fmt.Sprintf("COALESCE(%s, %s) AS %s", ref, value, col.Name()), nil
+}
+
+// isTimeZoneColumn return true if the column holds time zone aware timestamps the dialect can convert to a time zone
+func isTimeZoneColumn(d Dialect, col ColumnMeta) bool {
+	columnType := strings.ToLower(col.ColumnType())
+	switch d.Name() {
+	case "postgres":
+		return columnType == "timestamptz" || columnType == "timestamp with time zone"
+	case "mssql":
+		return columnType == "datetimeoffset"
+	case "mysql":
+		return columnType == "timestamp"
+	}
+	return false
+}
+
+// timeZoneExpr expression converting the timestamp to the time zone bound to the parameter
+func timeZoneExpr(d Dialect, ref, param string) string {
+	if d.Name() == "mysql" {
+		return fmt.Sprintf("CONVERT_TZ(%s, @@session.time_zone, %s)", ref, param)
+	}
+	return fmt.Sprintf("%s AT TIME ZONE %s", ref, param)
+}
+
+// coalesceDefault zero value literal of the column type replacing NULL values
+func coalesceDefault(col ColumnMeta) (string, bool) {
+	typeName := strings.ToLower(col.DatabaseTypeName())
+	switch {
+	case isTextColumn(col):
+		return "''", true
+	case strings.Contains(typeName, "bool"):
+		return "false", true
+	case strings.HasPrefix(typeName, "int"), strings.HasSuffix(typeName, "int"), strings.HasSuffix(typeName, "serial"),
+		strings.HasPrefix(typeName, "float"), strings.HasPrefix(typeName, "double"), typeName == "real",
+		strings.HasPrefix(typeName, "decimal"), strings.HasPrefix(typeName, "numeric"), typeName == "money":
+		return "0", true
+	}
+	return "", false
+}
+
+// columnsProjection table columns selected by a select statement
+func (opt SQLOptions) columnsProjection(dbTable DbTableMeta) (string, error) {
+	for name := range opt.CoalesceDefaults {
+		if _, ok := findColumn(dbTable, name); !ok {
+			return "", fmt.Errorf("table %s does not have a coalesce column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+
+	if len(opt.Columns) > 0 {
+		cols := make([]string, len(opt.Columns))
+		for i, name := range opt.Columns {
+			col, ok := findColumn(dbTable, name)
+			if !ok {
+				return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), name)
+			}
+
+			ref, err := opt.projectColumn(dbTable, col)
+			if err != nil {
+				return "", err
+			}
+			cols[i] = ref
+		}
+		return strings.Join(cols, ", "), nil
+	}
+
+	if opt.Include == nil {
+		if opt.CoalesceNulls {
+			return "", fmt.Errorf("table %s coalesce nulls requires an explicit projection, cannot generate sql", dbTable.TableName())
+		}
+		if opt.TimeZone {
+			return "", fmt.Errorf("table %s time zone conversion requires an explicit projection, cannot generate sql", dbTable.TableName())
+		}
+		return opt.columnRef("*"), nil
+	}
+
+	var cols []string
+	for _, col := range dbTable.Columns() {
+		if opt.includeColumn(col) {
+			ref, err := opt.projectColumn(dbTable, col)
+			if err != nil {
+				return "", err
+			}
+			cols = append(cols, ref)
+		}
+	}
+
+	if len(cols) == 0 {
+		return "", fmt.Errorf("table %s does not have any included columns, cannot generate sql", dbTable.TableName())
+	}
+	return strings.Join(cols, ", "), nil
+}
+
+// validatePredicates check the columns of the PredicateExpressions and Collations exist in the table, collated columns
+// must be text typed
+func (opt SQLOptions) validatePredicates(dbTable DbTableMeta) error {
+	for name := range opt.PredicateExpressions {
+		if _, ok := findColumn(dbTable, name); !ok {
+			return fmt.Errorf("table %s does not have a predicate expression column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+
+	for name := range opt.Collations {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return fmt.Errorf("table %s does not have a collated column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+		if !isTextColumn(col) {
+			return fmt.Errorf("table %s collated column %s is not a text column, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+	return nil
+}
+
+// keyPredicate predicate comparing a key column with the parameter
+func (opt SQLOptions) keyPredicate(d Dialect, col ColumnMeta, param string) string {
+	if collation, ok := opt.Collations[col.Name()]; ok {
+		if d.Name() == "postgres" {
+			collation = d.QuoteIdentifier(collation)
+		}
+		return fmt.Sprintf("%s COLLATE %s = %s", opt.columnRef(col.Name()), collation, param)
+	}
+
+	if expr, ok := opt.PredicateExpressions[col.Name()]; ok {
+		return fmt.Sprintf("%s = %s", strings.Replace(expr, predicateOperand, opt.columnRef(col.Name()), -1),
+			strings.Replace(expr, predicateOperand, param, -1))
+	}
+	if opt.CaseInsensitiveKeys && isTextColumn(col) {
+		return fmt.Sprintf("LOWER(%s) = LOWER(%s)", opt.columnRef(col.Name()), param)
+	}
+	return fmt.Sprintf("%s = %s", opt.columnRef(col.Name()), param)
+}
+
+// tableRef reference to the table, followed by the table alias and the index hint if set. Postgres selects are restricted
+// to the table itself with ONLY when SQLOptions.Only is set.
+func (opt SQLOptions) tableRef(d Dialect, dbTable DbTableMeta) string {
+	ref := opt.quotedTable(d, dbTable)
+	if opt.TableAlias != "" {
+		ref = ref + " " + opt.TableAlias
+	}
+	return ref + opt.indexHint(d, dbTable)
+}
+
+// quotedTable quoted name of the table the select generators read from, qualified with the SchemaPlaceholder by the
+// template generators and prefixed with ONLY on Postgres when SQLOptions.Only is set
+func (opt SQLOptions) quotedTable(d Dialect, dbTable DbTableMeta) string {
+	ref := d.QuoteIdentifier(opt.selectedTable(dbTable))
+	if opt.schemaTemplate {
+		ref = SchemaPlaceholder + "." + ref
+	}
+	if opt.Only && d.Name() == "postgres" {
+		ref = "ONLY " + ref
+	}
+	return ref
+}
+
+// selectedTable name of the table the select generators read from, the SQLOptions.Partition if set
+func (opt SQLOptions) selectedTable(dbTable DbTableMeta) string {
+	if opt.Partition != "" {
+		return opt.Partition
+	}
+	return dbTable.TableName()
+}
+
+// hinted prefix the sql with the pg_hint_plan IndexScan comment of the SQLOptions.IndexHint index on Postgres, which
+// only reads hints at the start of the statement. Other dialects hint the table reference.
+func (opt SQLOptions) hinted(d Dialect, dbTable DbTableMeta, sql string) string {
+	if opt.IndexHint == "" || d.Name() != "postgres" {
+		return sql
+	}
+
+	target := opt.selectedTable(dbTable)
+	if opt.TableAlias != "" {
+		target = opt.TableAlias
+	}
+	return fmt.Sprintf("/*+ IndexScan(%s %s) */ %s", target, opt.IndexHint, sql)
+}
+
+// indexHint hint of the dialect forcing the select to use the SQLOptions.IndexHint index, empty if not set or if the
+// dialect hints the statement instead
+func (opt SQLOptions) indexHint(d Dialect, dbTable DbTableMeta) string {
+	if opt.IndexHint == "" {
+		return ""
+	}
+
+	switch d.Name() {
+	case "postgres":
+		return ""
+	case "mssql":
+		return fmt.Sprintf(" WITH (INDEX(%s))", d.QuoteIdentifier(opt.IndexHint))
+	case "sqlite3":
+		return " INDEXED BY " + d.QuoteIdentifier(opt.IndexHint)
+	default:
+		return fmt.Sprintf(" USE INDEX (%s)", d.QuoteIdentifier(opt.IndexHint))
+	}
+}
+
+// validateIndexHint check the SQLOptions.IndexHint index is one of the known indexes of the table
+func (opt SQLOptions) validateIndexHint(dbTable DbTableMeta) error {
+	if opt.IndexHint == "" {
+		return nil
+	}
+
+	for _, idx := range dbTable.Indexes() {
+		if idx.Name == opt.IndexHint {
+			return nil
+		}
+	}
+	return fmt.Errorf("table %s does not have an index %s, cannot generate sql", dbTable.TableName(), opt.IndexHint)
+}
+
+// isTextColumn return true if the column holds character data
+func isTextColumn(col ColumnMeta) bool {
+	typeName := strings.ToLower(col.DatabaseTypeName())
+	return strings.Contains(typeName, "char") || strings.Contains(typeName, "text") || strings.Contains(typeName, "string") ||
+		strings.Contains(typeName, "clob")
+}
+
+// IsSafeIdentifier return true if the name can be used unquoted as an identifier in generated sql
+func IsSafeIdentifier(name string) bool {
+	return safeIdentifierRegex.MatchString(name)
+}
+
+func validateIdentifier(kind, name string) error {
+	if !IsSafeIdentifier(name) {
+		return fmt.Errorf("invalid %s %q, must start with a letter or underscore and contain only letters, digits and underscores", kind, name)
+	}
+	return nil
+}
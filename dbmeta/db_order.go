@@ -0,0 +1,92 @@
+package dbmeta
+
+import (
+	"fmt"
+)
+
+// NullsOrder position of NULL values in an ordered column
+type NullsOrder int
+
+const (
+	// NullsDefault use the dialect default, Postgres sorts NULL values as larger than any value while MySQL, Sqlite
+	// and SQL Server sort them as smaller
+	NullsDefault NullsOrder = iota
+	// NullsFirst sort NULL values before all other values
+	NullsFirst
+	// NullsLast sort NULL values after all other values
+	NullsLast
+)
+
+// String describe the nulls order
+func (nulls NullsOrder) String() string {
+	switch nulls {
+	case NullsDefault:
+		return "Default"
+	case NullsFirst:
+		return "NullsFirst"
+	case NullsLast:
+		return "NullsLast"
+	default:
+		return fmt.Sprintf("unknown nulls order: %d", int(nulls))
+	}
+}
+
+// OrderColumn column of an ORDER BY clause
+type OrderColumn struct {
+	// Column name of the ordered column
+	Column string
+
+	// Desc sort the column in descending order
+	Desc bool
+
+	// Nulls position of the NULL values of the column
+	Nulls NullsOrder
+}
+
+// orderBy terms of the ORDER BY clause for the SQLOptions.OrderBy columns, followed by the primary keys not already
+// ordered so the order is stable. NULLS FIRST and NULLS LAST are emitted for Postgres and Sqlite, other dialects are
+// emulated by first ordering by CASE WHEN col IS NULL.
+func (opt SQLOptions) orderBy(d Dialect, dbTable DbTableMeta) ([]string, error) {
+	var terms, ordered []string
+	for _, order := range opt.OrderBy {
+		if _, ok := findColumn(dbTable, order.Column); !ok {
+			return nil, fmt.Errorf("table %s does not have an order by column %s, cannot generate sql", dbTable.TableName(), order.Column)
+		}
+		ordered = append(ordered, order.Column)
+
+		col := opt.columnRef(order.Column)
+		direction := ""
+		if order.Desc {
+			direction = " DESC"
+		}
+
+		switch order.Nulls {
+		case NullsDefault:
+			terms = append(terms, col+direction)
+		case NullsFirst, NullsLast:
+			if d.Name() == "postgres" || d.Name() == "sqlite3" {
+				nulls := " NULLS FIRST"
+				if order.Nulls == NullsLast {
+					nulls = " NULLS LAST"
+				}
+				terms = append(terms, col+direction+nulls)
+				break
+			}
+
+			first, last := 0, 1
+			if order.Nulls == NullsLast {
+				first, last = 1, 0
+			}
+			terms = append(terms, fmt.Sprintf("CASE WHEN %s IS NULL THEN %d ELSE %d END", col, first, last), col+direction)
+		default:
+			return nil, fmt.Errorf("table %s order by column %s has %s, cannot generate sql", dbTable.TableName(), order.Column, order.Nulls)
+		}
+	}
+
+	for _, col := range dbTable.Columns() {
+		if _, ok := FindInSlice(ordered, col.Name()); col.IsPrimaryKey() && !ok {
+			terms = append(terms, opt.columnRef(col.Name()))
+		}
+	}
+	return terms, nil
+}
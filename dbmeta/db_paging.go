@@ -0,0 +1,280 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Direction direction to page through records with a cursor
+type Direction int
+
+const (
+	// Forward page to the records after the cursor
+	Forward Direction = iota
+	// Backward page to the records before the cursor
+	Backward
+)
+
+// String describe the direction
+func (dir Direction) String() string {
+	switch dir {
+	case Forward:
+		return "Forward"
+	case Backward:
+		return "Backward"
+	default:
+		return fmt.Sprintf("unknown direction: %d", int(dir))
+	}
+}
+
+// GenerateCursorPageSQL generate sql for keyset (cursor) pagination ordered by the primary keys. The cursor parameters
+// are the primary key values of the last (Forward) or first (Backward) record of the current page, followed by the page
+// size. Backward pages are ordered in reverse so the results must be reversed in memory before they are displayed.
+// Composite keys are compared as row values, which SQL Server does not support, SQL Server limits the page with TOP.
+// Soft deleted records are excluded unless SQLOptions.IncludeDeleted is set, tenant scoped records are matched on the
+// tenant bound after the cursor parameters and before the page size.
+func GenerateCursorPageSQL(dbTable DbTableMeta, direction Direction, namedParams bool, opts ...SQLOptions) (string, error) {
+	primaryCnt := PrimaryKeyCount(dbTable)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	comparison := ">"
+	sortOrder := ""
+	switch direction {
+	case Forward:
+	case Backward:
+		comparison = "<"
+		sortOrder = " DESC"
+	default:
+		return "", fmt.Errorf("invalid cursor direction %s, cannot generate sql", direction)
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	if primaryCnt > 1 && d.Name() == "mssql" {
+		return "", fmt.Errorf("table %s has a composite primary key, dialect %s does not support row value comparisons", dbTable.TableName(), d.Name())
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	var keys, params, orderBy []string
+	pos := 1
+	for _, col := range dbTable.Columns() {
+		if col.IsPrimaryKey() {
+			param := d.Placeholder(pos)
+			if namedParams {
+				param = d.NamedPlaceholder(fmt.Sprintf("cursor_%s", col.Name()))
+			}
+
+			keys = append(keys, opt.columnRef(col.Name()))
+			params = append(params, param)
+			orderBy = append(orderBy, opt.columnRef(col.Name())+sortOrder)
+			pos++
+		}
+	}
+
+	var tenant *keyWhere
+	if tenantCol != nil {
+		tenant = newKeyWhere(d, opt, namedParams, pos)
+		tenant.addTenant(tenantCol)
+		pos++
+	}
+
+	limit := d.Placeholder(pos)
+	if namedParams {
+		limit = d.NamedPlaceholder("page_size")
+	}
+
+	if d.Name() == "mssql" {
+		projection = fmt.Sprintf("TOP (%s) %s", limit, projection)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE ", projection, opt.tableRef(d, dbTable)))
+	if len(keys) == 1 {
+		buf.WriteString(fmt.Sprintf("%s %s %s", keys[0], comparison, params[0]))
+	} else {
+		buf.WriteString(fmt.Sprintf("(%s) %s (%s)", strings.Join(keys, ", "), comparison, strings.Join(params, ", ")))
+	}
+	if tenant != nil {
+		buf.WriteString(" AND " + tenant.String())
+	}
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	buf.WriteString(fmt.Sprintf(" ORDER BY %s", strings.Join(orderBy, ", ")))
+	if d.Name() != "mssql" {
+		buf.WriteString(fmt.Sprintf(" LIMIT %s", limit))
+	}
+	return opt.routed(opt.annotated(dbTable, "select_cursor_page", opt.hinted(d, dbTable, buf.String()))), nil
+}
+
+// GenerateSelectKeyRangeSQL generate sql to select the records with primary keys between a lower and upper bound, both
+// inclusive, ordered by the primary keys. The parameters are the lower bound keys followed by the upper bound keys.
+// Composite keys are compared as row values, which SQL Server does not support, a single key uses BETWEEN. Soft deleted
+// records are excluded unless SQLOptions.IncludeDeleted is set, tenant scoped records are matched on the tenant bound
+// after the upper bound keys.
+func GenerateSelectKeyRangeSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	keyCols := primaryKeyColumns(dbTable)
+
+	if len(keyCols) == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	if len(keyCols) > 1 && d.Name() == "mssql" {
+		return "", fmt.Errorf("table %s has a composite primary key, dialect %s does not support row value comparisons", dbTable.TableName(), d.Name())
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	var keys, lower, upper []string
+	for i, col := range keyCols {
+		from := d.Placeholder(i + 1)
+		to := d.Placeholder(len(keyCols) + i + 1)
+		if namedParams {
+			from = d.NamedPlaceholder(fmt.Sprintf("range_from_%s", col.Name()))
+			to = d.NamedPlaceholder(fmt.Sprintf("range_to_%s", col.Name()))
+		}
+
+		keys = append(keys, opt.columnRef(col.Name()))
+		lower = append(lower, from)
+		upper = append(upper, to)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE ", projection, opt.tableRef(d, dbTable)))
+	if len(keys) == 1 {
+		buf.WriteString(fmt.Sprintf("%s BETWEEN %s AND %s", keys[0], lower[0], upper[0]))
+	} else {
+		buf.WriteString(fmt.Sprintf("(%s) >= (%s) AND (%s) <= (%s)",
+			strings.Join(keys, ", "), strings.Join(lower, ", "), strings.Join(keys, ", "), strings.Join(upper, ", ")))
+	}
+	if tenantCol != nil {
+		where := newKeyWhere(d, opt, namedParams, 2*len(keyCols)+1)
+		where.addTenant(tenantCol)
+		buf.WriteString(" AND " + where.String())
+	}
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	buf.WriteString(fmt.Sprintf(" ORDER BY %s", strings.Join(keys, ", ")))
+	return opt.routed(opt.annotated(dbTable, "select_key_range", opt.hinted(d, dbTable, buf.String()))), nil
+}
+
+// GenerateSelectPageSQL generate sql to select a page of records ordered by SQLOptions.OrderBy and the primary keys,
+// the parameters are the page size followed by the offset. Tenant scoped records are matched on the tenant bound to the
+// first parameter, before the page size. Soft deleted records are excluded unless SQLOptions.IncludeDeleted is set. SQL
+// Server requires an ORDER BY for OFFSET ... FETCH NEXT, versions before 2012 are paged with SELECT TOP and
+// ROW_NUMBER() when LegacyPaging is set.
+func GenerateSelectPageSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	primaryCnt := PrimaryKeyCount(dbTable)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	orderBy, err := opt.orderBy(d, dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	filter := scopeFilter(d, dbTable, opt, tenantCol, namedParams, 1)
+	pos := 1
+	if tenantCol != nil {
+		pos++
+	}
+
+	limit := d.Placeholder(pos)
+	offset := d.Placeholder(pos + 1)
+	if namedParams {
+		limit = d.NamedPlaceholder("page_size")
+		offset = d.NamedPlaceholder("page_offset")
+	}
+
+	if d.Name() != "mssql" {
+		sql := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %s OFFSET %s",
+			projection, opt.tableRef(d, dbTable), filter, strings.Join(orderBy, ", "), limit, offset)
+		return opt.routed(opt.annotated(dbTable, "select_page", opt.hinted(d, dbTable, sql))), nil
+	}
+
+	if !opt.LegacyPaging {
+		sql := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s OFFSET %s ROWS FETCH NEXT %s ROWS ONLY",
+			projection, opt.tableRef(d, dbTable), filter, strings.Join(orderBy, ", "), offset, limit)
+		return opt.routed(opt.annotated(dbTable, "select_page", sql)), nil
+	}
+
+	// the outer query selects from the derived table so the alias does not apply
+	outer := opt
+	outer.TableAlias = ""
+	outer.Computed = nil
+	for _, computed := range opt.Computed {
+		outer.Computed = append(outer.Computed, ComputedColumn{Expr: computed.Alias, Alias: computed.Alias})
+	}
+	outerProjection, err := outer.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	sql := fmt.Sprintf("SELECT TOP (%s) %s FROM (SELECT %s, ROW_NUMBER() OVER (ORDER BY %s) AS row_num FROM %s%s) AS paged WHERE row_num > %s ORDER BY row_num",
+		limit, outerProjection, projection, strings.Join(orderBy, ", "), opt.tableRef(d, dbTable), filter, offset)
+	return opt.routed(opt.annotated(dbTable, "select_page", sql)), nil
+}
@@ -0,0 +1,271 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateCursorPageSQL(t *testing.T) {
+	tenantID := testColumn("tenant_id", "UUID", 0)
+	tenantID.isPrimaryKey = true
+	code := testColumn("code", "TEXT", 1)
+	code.isPrimaryKey = true
+
+	composite := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "products",
+		columns:   []*columnMeta{tenantID, code, testColumn("name", "TEXT", 2)},
+	}
+
+	mssqlUsers := testUsersTable()
+	mssqlUsers.sqlType = "mssql"
+
+	deleted := testUsersTable()
+	deleted.columns = append(deleted.columns, testColumn("deleted_at", "TIMESTAMP", 3))
+
+	tests := []struct {
+		name      string
+		table     *dbTableMeta
+		direction Direction
+		expected  string
+	}{
+		{"mssql", mssqlUsers, Forward, `SELECT TOP (@p2) * FROM [users] WHERE id > @p1 ORDER BY id`},
+		{"soft delete", deleted, Forward, `SELECT * FROM "users" WHERE id > $1 AND deleted_at IS NULL ORDER BY id LIMIT $2`},
+		{"forward", testUsersTable(), Forward, `SELECT * FROM "users" WHERE id > $1 ORDER BY id LIMIT $2`},
+		{"backward", testUsersTable(), Backward, `SELECT * FROM "users" WHERE id < $1 ORDER BY id DESC LIMIT $2`},
+		{"composite", composite, Forward, `SELECT * FROM "products" WHERE (tenant_id, code) > ($1, $2) ORDER BY tenant_id, code LIMIT $3`},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateCursorPageSQL(tt.table, tt.direction, false)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+
+	composite.sqlType = "mssql"
+	_, err := GenerateCursorPageSQL(composite, Forward, false)
+	if err == nil {
+		t.Errorf("expect error for composite key cursor on mssql")
+	}
+}
+
+func Test_GenerateSelectPageSQL(t *testing.T) {
+	mssqlUsers := testUsersTable()
+	mssqlUsers.sqlType = "mssql"
+
+	tests := []struct {
+		name     string
+		table    *dbTableMeta
+		opt      SQLOptions
+		expected string
+	}{
+		{"postgres", testUsersTable(), DefaultSQLOptions(), `SELECT * FROM "users" ORDER BY id LIMIT $1 OFFSET $2`},
+		{"mssql", mssqlUsers, DefaultSQLOptions(), `SELECT * FROM [users] ORDER BY id OFFSET @p2 ROWS FETCH NEXT @p1 ROWS ONLY`},
+		{"mssql alias", mssqlUsers, SQLOptions{TableAlias: "u"}, `SELECT u.* FROM [users] u ORDER BY u.id OFFSET @p2 ROWS FETCH NEXT @p1 ROWS ONLY`},
+		{"mssql legacy", mssqlUsers, SQLOptions{LegacyPaging: true},
+			`SELECT TOP (@p1) * FROM (SELECT *, ROW_NUMBER() OVER (ORDER BY id) AS row_num FROM [users]) AS paged WHERE row_num > @p2 ORDER BY row_num`},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateSelectPageSQL(tt.table, false, tt.opt)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+
+	sql, err := GenerateSelectPageSQL(mssqlUsers, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT * FROM [users] ORDER BY id OFFSET @page_offset ROWS FETCH NEXT @page_size ROWS ONLY`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectPageSQLOrderBy(t *testing.T) {
+	opt := SQLOptions{OrderBy: []OrderColumn{{Column: "email", Desc: true, Nulls: NullsLast}, {Column: "name"}}}
+
+	sql, err := GenerateSelectPageSQL(testUsersTable(), false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT * FROM "users" ORDER BY email DESC NULLS LAST, name, id LIMIT $1 OFFSET $2`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	mysqlUsers := testUsersTable()
+	mysqlUsers.sqlType = "mysql"
+	sql, err = GenerateSelectAllSQL(mysqlUsers, SQLOptions{OrderBy: []OrderColumn{{Column: "email", Nulls: NullsFirst}, {Column: "id", Desc: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "SELECT * FROM `users` ORDER BY CASE WHEN email IS NULL THEN 0 ELSE 1 END, email, id DESC"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectPageSQL(testUsersTable(), false, SQLOptions{OrderBy: []OrderColumn{{Column: "missing"}}})
+	if err == nil {
+		t.Errorf("expect error for unknown order by column")
+	}
+}
+
+func Test_GenerateSelectKeyRangeSQL(t *testing.T) {
+	sql, err := GenerateSelectKeyRangeSQL(testUsersTable(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id BETWEEN $1 AND $2 ORDER BY id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	composite := testUsersTable()
+	composite.columns[1].isPrimaryKey = true
+	sql, err = GenerateSelectKeyRangeSQL(composite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE (id, name) >= ($1, $2) AND (id, name) <= ($3, $4) ORDER BY id, name`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	composite.sqlType = "mssql"
+	_, err = GenerateSelectKeyRangeSQL(composite, false)
+	if err == nil {
+		t.Errorf("expect error for mssql composite key range")
+	}
+}
+
+func Test_GenerateCursorPageSQLTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("deleted_at", "TIMESTAMP", 4))
+
+	sql, err := GenerateCursorPageSQL(table, Forward, false, SQLOptions{DetectTenant: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id > $1 AND tenant_id = $2 AND deleted_at IS NULL ORDER BY id LIMIT $3`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mssql"
+	sql, err = GenerateCursorPageSQL(table, Forward, true, SQLOptions{TenantColumn: "tenant_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT TOP (@page_size) * FROM [users] WHERE id > @cursor_id AND tenant_id = @where_tenant_id_2 AND deleted_at IS NULL ORDER BY id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateCursorPageSQL(testUsersTable(), Forward, false, SQLOptions{TenantColumn: "tenant_id"})
+	if err == nil {
+		t.Errorf("expect error for a missing tenant column")
+	}
+}
+
+func Test_GenerateSelectKeyRangeSQLTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3))
+	table.columns[1].isPrimaryKey = true
+
+	sql, err := GenerateSelectKeyRangeSQL(table, false, SQLOptions{TenantColumn: "tenant_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE (id, name) >= ($1, $2) AND (id, name) <= ($3, $4) AND tenant_id = $5 ORDER BY id, name`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectKeyRangeSQL(testUsersTable(), false, SQLOptions{TenantColumn: "tenant_id"})
+	if err == nil {
+		t.Errorf("expect error for a missing tenant column")
+	}
+}
+
+func Test_GenerateSelectPageSQLTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3))
+	mssqlTable := testUsersTable()
+	mssqlTable.sqlType = "mssql"
+	mssqlTable.columns = table.columns
+
+	tests := []struct {
+		name     string
+		table    *dbTableMeta
+		opt      SQLOptions
+		expected string
+	}{
+		{"postgres", table, SQLOptions{TenantColumn: "tenant_id"}, `SELECT * FROM "users" WHERE tenant_id = $1 ORDER BY id LIMIT $2 OFFSET $3`},
+		{"mssql", mssqlTable, SQLOptions{DetectTenant: true},
+			`SELECT * FROM [users] WHERE tenant_id = @p1 ORDER BY id OFFSET @p3 ROWS FETCH NEXT @p2 ROWS ONLY`},
+		{"mssql legacy", mssqlTable, SQLOptions{DetectTenant: true, LegacyPaging: true},
+			`SELECT TOP (@p2) * FROM (SELECT *, ROW_NUMBER() OVER (ORDER BY id) AS row_num FROM [users] WHERE tenant_id = @p1) AS paged WHERE row_num > @p3 ORDER BY row_num`},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateSelectPageSQL(tt.table, false, tt.opt)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+
+	_, err := GenerateSelectPageSQL(testUsersTable(), false, SQLOptions{TenantColumn: "tenant_id"})
+	if err == nil {
+		t.Errorf("expect error for a missing tenant column")
+	}
+}
+
+func Test_GenerateSelectPageSQLSoftDelete(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("deleted_at", "TIMESTAMP", 4))
+	mssqlTable := testUsersTable()
+	mssqlTable.sqlType = "mssql"
+	mssqlTable.columns = table.columns
+
+	tests := []struct {
+		name     string
+		table    *dbTableMeta
+		opt      SQLOptions
+		expected string
+	}{
+		{"postgres", table, DefaultSQLOptions(), `SELECT * FROM "users" WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`},
+		{"tenant", table, SQLOptions{DetectTenant: true},
+			`SELECT * FROM "users" WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY id LIMIT $2 OFFSET $3`},
+		{"include deleted", table, SQLOptions{IncludeDeleted: true}, `SELECT * FROM "users" ORDER BY id LIMIT $1 OFFSET $2`},
+		{"mssql", mssqlTable, DefaultSQLOptions(),
+			`SELECT * FROM [users] WHERE deleted_at IS NULL ORDER BY id OFFSET @p2 ROWS FETCH NEXT @p1 ROWS ONLY`},
+		{"mssql legacy", mssqlTable, SQLOptions{LegacyPaging: true},
+			`SELECT TOP (@p1) * FROM (SELECT *, ROW_NUMBER() OVER (ORDER BY id) AS row_num FROM [users] WHERE deleted_at IS NULL) AS paged WHERE row_num > @p2 ORDER BY row_num`},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateSelectPageSQL(tt.table, false, tt.opt)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// GenerateSelectPolymorphicSQL generate sql selecting the records of a polymorphic table that reference a record of one
+// type, e.g. the comments of a post with WHERE type = 'post' AND foreign_id = $1. The type value is emitted as a quoted
+// literal so each referenced type gets its own statement, the referenced id is the only parameter followed by the
+// tenant of tenant scoped records.
+func GenerateSelectPolymorphicSQL(dbTable DbTableMeta, typeCol, idCol, typeValue string, namedParams bool, opts ...SQLOptions) (string, error) {
+	discriminator, ok := findColumn(dbTable, typeCol)
+	if !ok {
+		return "", fmt.Errorf("table %s does not have a type column %s, cannot generate sql", dbTable.TableName(), typeCol)
+	}
+
+	id, ok := findColumn(dbTable, idCol)
+	if !ok {
+		return "", fmt.Errorf("table %s does not have an id column %s, cannot generate sql", dbTable.TableName(), idCol)
+	}
+
+	if typeValue == "" {
+		return "", fmt.Errorf("table %s type column %s requires a type value, cannot generate sql", dbTable.TableName(), typeCol)
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	where := newKeyWhere(d, opt, namedParams, 1)
+	where.addKey(id)
+	if tenantCol != nil {
+		where.addTenant(tenantCol)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s AND %s", projection, opt.tableRef(d, dbTable),
+		opt.columnRef(discriminator.Name()), quoteLiteral(typeValue), where.String()))
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	return opt.routed(opt.annotated(dbTable, "select_polymorphic", opt.hinted(d, dbTable, buf.String()))), nil
+}
@@ -0,0 +1,38 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateSelectPolymorphicSQL(t *testing.T) {
+	comments := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "comments",
+		columns: []*columnMeta{
+			testColumn("id", "INT4", 0),
+			testColumn("type", "VARCHAR", 1),
+			testColumn("foreign_id", "INT4", 2),
+		},
+	}
+	comments.columns[0].isPrimaryKey = true
+
+	sql, err := GenerateSelectPolymorphicSQL(comments, "type", "foreign_id", "post's", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "comments" WHERE type = 'post''s' AND foreign_id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectPolymorphicSQL(comments, "kind", "foreign_id", "post", false)
+	if err == nil {
+		t.Errorf("expect error for unknown type column")
+	}
+
+	_, err = GenerateSelectPolymorphicSQL(comments, "type", "post_id", "post", false)
+	if err == nil {
+		t.Errorf("expect error for unknown id column")
+	}
+}
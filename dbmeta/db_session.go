@@ -0,0 +1,27 @@
+package dbmeta
+
+import (
+	"fmt"
+)
+
+// GenerateSetSearchPathSQL generate sql pinning the Postgres search_path to the schema for the rest of the current
+// transaction, so unqualified table references cannot resolve to a table of another schema. The setting is reverted
+// when the transaction ends.
+func GenerateSetSearchPathSQL(schema string) (string, error) {
+	err := validateIdentifier("schema name", schema)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SET LOCAL search_path TO %s", DialectPostgres.QuoteIdentifier(schema)), nil
+}
+
+// GenerateSetLockTimeoutSQL generate sql limiting how long the statements of the current Postgres transaction wait for a
+// lock, in milliseconds, 0 waits indefinitely. Run it in the transaction before a select generated with
+// SQLOptions.Lock set to LockForUpdate, so a locked record fails the select with lock_not_available instead of
+// blocking the caller.
+func GenerateSetLockTimeoutSQL(ms int) (string, error) {
+	if ms < 0 {
+		return "", fmt.Errorf("invalid lock timeout %d, must not be negative", ms)
+	}
+	return fmt.Sprintf("SET LOCAL lock_timeout = %d", ms), nil
+}
@@ -0,0 +1,39 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateSetSearchPathSQL(t *testing.T) {
+	sql, err := GenerateSetSearchPathSQL("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SET LOCAL search_path TO "billing"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSetSearchPathSQL(`billing"; DROP TABLE users; --`)
+	if err == nil {
+		t.Errorf("expect error for unsafe schema name")
+	}
+}
+
+func Test_GenerateSetLockTimeoutSQL(t *testing.T) {
+	sql, err := GenerateSetLockTimeoutSQL(2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SET LOCAL lock_timeout = 2500`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSetLockTimeoutSQL(-1)
+	if err == nil {
+		t.Errorf("expect error for negative lock timeout")
+	}
+}
@@ -0,0 +1,68 @@
+package dbmeta
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/inflection"
+)
+
+// Operation statement generated for a table by GenerateAnnotatedQuery
+type Operation int
+
+const (
+	// OperationSelectOne select one record by primary key, sqlc :one query named GetUser
+	OperationSelectOne Operation = iota
+	// OperationSelectAll select every record, sqlc :many query named ListUsers
+	OperationSelectAll
+	// OperationInsert insert a record, sqlc :exec query named CreateUser
+	OperationInsert
+	// OperationUpdate update a record by primary key, sqlc :exec query named UpdateUser
+	OperationUpdate
+	// OperationDelete delete a record by primary key, sqlc :exec query named DeleteUser
+	OperationDelete
+	// OperationUpsert insert or update a record, sqlc :exec query named UpsertUser
+	OperationUpsert
+)
+
+// GenerateAnnotatedQuery generate the query of the operation in the sqlc query file format, a -- name: GetUser :one
+// comment followed by the sql of the corresponding generator terminated by a semicolon. The query name is derived from
+// the table name, singular for the record operations and plural for OperationSelectAll. Queries use positional
+// parameters, sqlc does not support SQL Server.
+func GenerateAnnotatedQuery(dbTable DbTableMeta, op Operation) (string, error) {
+	d := DialectForTable(dbTable)
+	if d.Name() == "mssql" {
+		return "", fmt.Errorf("table %s dialect %s is not supported by sqlc, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	record := FmtFieldName(inflection.Singular(dbTable.TableName()))
+
+	var name, cmd, sql string
+	var err error
+	switch op {
+	case OperationSelectOne:
+		name, cmd = "Get"+record, ":one"
+		sql, err = GenerateSelectOneSQL(dbTable, false)
+	case OperationSelectAll:
+		name, cmd = "List"+FmtFieldName(inflection.Plural(dbTable.TableName())), ":many"
+		sql, err = GenerateSelectAllSQL(dbTable)
+	case OperationInsert:
+		name, cmd = "Create"+record, ":exec"
+		sql, err = GenerateInsertSQL(dbTable, false)
+	case OperationUpdate:
+		name, cmd = "Update"+record, ":exec"
+		sql, err = GenerateUpdateSQL(dbTable, false)
+	case OperationDelete:
+		name, cmd = "Delete"+record, ":exec"
+		sql, err = GenerateHardDeleteSQL(dbTable, false)
+	case OperationUpsert:
+		name, cmd = "Upsert"+record, ":exec"
+		sql, err = GenerateUpsertSQL(dbTable, false)
+	default:
+		return "", fmt.Errorf("table %s operation %d is not supported, cannot generate sql", dbTable.TableName(), op)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("-- name: %s %s\n%s;\n", name, cmd, sql), nil
+}
@@ -0,0 +1,40 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateAnnotatedQuery(t *testing.T) {
+	table := testUsersTable()
+
+	tests := []struct {
+		op       Operation
+		expected string
+	}{
+		{OperationSelectOne, "-- name: GetUser :one\nSELECT * FROM \"users\" WHERE id = $1;\n"},
+		{OperationSelectAll, "-- name: ListUsers :many\nSELECT * FROM \"users\";\n"},
+		{OperationInsert, "-- name: CreateUser :exec\nINSERT INTO \"users\" (name, email) VALUES ($1, $2);\n"},
+		{OperationUpdate, "-- name: UpdateUser :exec\nUPDATE \"users\" SET name = $1, email = $2 WHERE id = $3;\n"},
+	}
+
+	for _, test := range tests {
+		query, err := GenerateAnnotatedQuery(table, test.op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.expected {
+			t.Errorf("expect: %s, but got %s", test.expected, query)
+		}
+	}
+
+	_, err := GenerateAnnotatedQuery(table, Operation(99))
+	if err == nil {
+		t.Errorf("expect error for unknown operation")
+	}
+
+	table.sqlType = "mssql"
+	_, err = GenerateAnnotatedQuery(table, OperationSelectOne)
+	if err == nil {
+		t.Errorf("expect error for dialect unsupported by sqlc")
+	}
+}
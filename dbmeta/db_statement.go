@@ -0,0 +1,216 @@
+package dbmeta
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var statementNameRegex = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// StatementParam parameter bound to a generated statement
+type StatementParam struct {
+	// Name name of the placeholder when named parameters are used, otherwise the column name
+	Name string
+
+	// Column column the parameter is compared with or assigned to
+	Column string
+
+	// IsUUID true if the column is a uuid type, string values should be parsed into a uuid before they are bound
+	IsUUID bool
+
+	// SQLType declared sql type of the column, e.g. to send typed Parse messages, empty if not bound to a column
+	SQLType string
+
+	// OID postgres type oid of the column type, e.g. for the parameter types of an extended protocol Parse, 0 if the
+	// type is not known and should be inferred by the server
+	OID uint32
+}
+
+// postgresTypeOIDs oids of the common postgres types, keyed by the lower case type name and its aliases
+var postgresTypeOIDs = map[string]uint32{
+	"bool":                     16,
+	"boolean":                  16,
+	"bytea":                    17,
+	"int8":                     20,
+	"bigint":                   20,
+	"bigserial":                20,
+	"int2":                     21,
+	"smallint":                 21,
+	"int4":                     23,
+	"int":                      23,
+	"integer":                  23,
+	"serial":                   23,
+	"text":                     25,
+	"oid":                      26,
+	"json":                     114,
+	"float4":                   700,
+	"real":                     700,
+	"float8":                   701,
+	"double precision":         701,
+	"inet":                     869,
+	"bpchar":                   1042,
+	"char":                     1042,
+	"character":                1042,
+	"varchar":                  1043,
+	"character varying":        1043,
+	"date":                     1082,
+	"time":                     1083,
+	"timestamp":                1114,
+	"timestamptz":              1184,
+	"timestamp with time zone": 1184,
+	"interval":                 1186,
+	"numeric":                  1700,
+	"decimal":                  1700,
+	"uuid":                     2950,
+	"jsonb":                    3802,
+	"_bool":                    1000,
+	"_int2":                    1005,
+	"_int4":                    1007,
+	"_text":                    1009,
+	"_varchar":                 1015,
+	"_int8":                    1016,
+	"_float8":                  1022,
+	"_uuid":                    2951,
+}
+
+// PostgresTypeOID postgres type oid of the sql type, ok is false if the type is not one of the common built-in types
+func PostgresTypeOID(sqlType string) (oid uint32, ok bool) {
+	name := strings.ToLower(strings.TrimSpace(sqlType))
+	if strings.HasSuffix(name, "[]") {
+		name = "_" + strings.TrimSuffix(name, "[]")
+	}
+	oid, ok = postgresTypeOIDs[name]
+	return oid, ok
+}
+
+// newStatementParam parameter bound to the column
+func newStatementParam(name string, col ColumnMeta) StatementParam {
+	oid, _ := PostgresTypeOID(col.ColumnType())
+	return StatementParam{Name: name, Column: col.Name(), IsUUID: col.IsUUID(), SQLType: col.ColumnType(), OID: oid}
+}
+
+// GeneratedStatement sql statement along with a stable name that can be used to prepare the statement once and reuse it
+type GeneratedStatement struct {
+	// Name deterministic name of the statement, derived from the table, operation and parameters
+	Name string
+
+	// SQL sql of the statement
+	SQL string
+
+	// Params parameters of the statement in the order they are bound
+	Params []StatementParam
+
+	// Columns names of the columns returned by the statement in result order, empty if it does not return rows
+	Columns []string
+
+	// JSONColumns names of the returned columns holding json, scanned as raw json so a json null is distinct from NULL
+	JSONColumns []string
+
+	// TenantParam position of the tenant parameter in Params starting at 1, 0 if the statement is not tenant scoped
+	TenantParam int
+}
+
+// NamedArgs named arguments for the parameters of a statement generated with named parameters, in binding order. Only
+// the names are set, the caller fills in the values before passing them to the driver, e.g. go-mssqldb binds
+// sql.Named("where_id_1", v) to @where_id_1.
+func (stmt *GeneratedStatement) NamedArgs() []sql.NamedArg {
+	args := make([]sql.NamedArg, len(stmt.Params))
+	for i, param := range stmt.Params {
+		args[i] = sql.NamedArg{Name: param.Name}
+	}
+	return args
+}
+
+// ParamOIDs postgres type oids of the parameters of a statement in binding order, unknown types are 0 so the server
+// infers them
+func (stmt *GeneratedStatement) ParamOIDs() []uint32 {
+	oids := make([]uint32, len(stmt.Params))
+	for i, param := range stmt.Params {
+		oids[i] = param.OID
+	}
+	return oids
+}
+
+// StatementID short stable hash of the sql, the first 12 hex digits of the SHA-256 of the sql with its whitespace
+// normalized, e.g. to group the latency metrics of a generated statement
+func StatementID(sql string) string {
+	h := sha256.Sum256([]byte(strings.Join(strings.Fields(sql), " ")))
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// withStatementID prefix the sql with a /* id:xxxx */ comment holding its StatementID
+func withStatementID(sql string) string {
+	return fmt.Sprintf("/* id:%s */ %s", StatementID(sql), sql)
+}
+
+// StatementName return a deterministic name for a statement on the table. The name is prefixed with the sanitized table
+// name and operation and suffixed with a digest of the table, operation and parameter signature, so tables with the same
+// column shapes, or table names that sanitize alike, do not collide.
+func StatementName(dbTable DbTableMeta, operation string, params []StatementParam) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%q %q", dbTable.TableName(), operation)
+	for _, param := range params {
+		_, _ = fmt.Fprintf(h, " %q %q", param.Name, param.Column)
+	}
+
+	name := strings.ToLower(dbTable.TableName() + "_" + operation)
+	name = strings.Trim(statementNameRegex.ReplaceAllString(name, "_"), "_")
+	return fmt.Sprintf("%s_%s", name, hex.EncodeToString(h.Sum(nil))[:8])
+}
+
+// RenumberPlaceholders shift the $N placeholders in the sql so $1 becomes $startAt, allowing a generated fragment to be
+// appended to a statement that already uses placeholders. The next free placeholder index is returned with the sql.
+// Placeholders in string literals and quoted identifiers are left as is.
+func RenumberPlaceholders(sql string, startAt int) (string, int) {
+	offset := startAt - 1
+	next := startAt
+
+	buf := strings.Builder{}
+	inString, inIdentifier := false, false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\'' && !inIdentifier {
+			inString = !inString
+		}
+		if c == '"' && !inString {
+			inIdentifier = !inIdentifier
+		}
+
+		if c != '$' || inString || inIdentifier || (i > 0 && isIdentifierByte(sql[i-1])) {
+			buf.WriteByte(c)
+			continue
+		}
+
+		j := i + 1
+		for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+			j++
+		}
+		if j == i+1 {
+			buf.WriteByte(c)
+			continue
+		}
+
+		pos, err := strconv.Atoi(sql[i+1 : j])
+		if err != nil {
+			buf.WriteString(sql[i:j])
+		} else {
+			pos += offset
+			if pos >= next {
+				next = pos + 1
+			}
+			buf.WriteString(fmt.Sprintf("$%d", pos))
+		}
+		i = j - 1
+	}
+	return buf.String(), next
+}
+
+// isIdentifierByte return true if the byte can be part of an unquoted identifier
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
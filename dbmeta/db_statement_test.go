@@ -0,0 +1,158 @@
+package dbmeta
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_RenumberPlaceholders(t *testing.T) {
+	tests := []struct {
+		sql      string
+		startAt  int
+		expected string
+		next     int
+	}{
+		{"id = $1 AND name = $2", 3, "id = $3 AND name = $4", 5},
+		{"id = $1 AND note = '$1'", 2, "id = $2 AND note = '$1'", 3},
+		{"name = $1 OR alias = $1", 1, "name = $1 OR alias = $1", 2},
+		{"deleted_at IS NULL", 4, "deleted_at IS NULL", 4},
+	}
+
+	for _, tt := range tests {
+		sql, next := RenumberPlaceholders(tt.sql, tt.startAt)
+		if sql != tt.expected || next != tt.next {
+			t.Errorf("expect: %s next %d, but got %s next %d", tt.expected, tt.next, sql, next)
+		}
+	}
+}
+
+func Test_GenerateSelectOneStatementUUID(t *testing.T) {
+	id := testColumn("id", "UUID", 0)
+	id.isPrimaryKey = true
+	sessions := &dbTableMeta{sqlType: "postgres", tableName: "sessions", columns: []*columnMeta{id, testColumn("data", "TEXT", 1)}}
+
+	stmt, err := GenerateSelectOneStatement(sessions, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stmt.Params) != 1 || !stmt.Params[0].IsUUID {
+		t.Errorf("expect uuid param, but got %v", stmt.Params)
+	}
+
+	if sessions.columns[1].IsUUID() {
+		t.Errorf("expect text column to not be a uuid")
+	}
+}
+
+func Test_GeneratedStatementNamedArgs(t *testing.T) {
+	table := testUsersTable()
+	table.sqlType = "mssql"
+	table.columns[1].isPrimaryKey = true
+
+	stmt, err := GenerateSelectOneStatement(table, true, SQLOptions{AsOf: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM [users] FOR SYSTEM_TIME AS OF @as_of WHERE id = @where_id_2 AND name = @where_name_3`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	args := stmt.NamedArgs()
+	names := []string{"as_of", "where_id_2", "where_name_3"}
+	if len(args) != len(names) {
+		t.Fatalf("expect %d args, but got %v", len(names), args)
+	}
+	for i, arg := range args {
+		if arg.Name != names[i] || arg.Value != nil {
+			t.Errorf("expect: %s, but got %v", names[i], arg)
+		}
+	}
+}
+
+func Test_GenerateSelectOneStatementSQLType(t *testing.T) {
+	table := testUsersTable()
+	table.columns[1].isPrimaryKey = true
+
+	stmt, err := GenerateSelectOneStatement(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"INT4", "VARCHAR"}
+	for i, param := range stmt.Params {
+		if param.SQLType != expected[i] {
+			t.Errorf("expect: %s, but got %s", expected[i], param.SQLType)
+		}
+	}
+}
+
+func Test_GeneratedStatementParamOIDs(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tags", "_TEXT", 3), testColumn("shape", "GEOMETRY", 4))
+	table.columns[0].isAutoIncrement = false
+
+	stmt, err := GenerateInsertStatement(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint32{23, 1043, 1043, 1009, 0}
+	oids := stmt.ParamOIDs()
+	if len(oids) != len(expected) {
+		t.Fatalf("expect: %v, but got %v", expected, oids)
+	}
+	for i := range expected {
+		if oids[i] != expected[i] {
+			t.Errorf("expect: %v, but got %v", expected, oids)
+			break
+		}
+	}
+
+	if oid, ok := PostgresTypeOID("uuid[]"); !ok || oid != 2951 {
+		t.Errorf("expect: 2951, but got %d", oid)
+	}
+}
+
+func Test_StatementID(t *testing.T) {
+	id := StatementID(`SELECT * FROM "users" WHERE id = $1`)
+	if len(id) != 12 {
+		t.Errorf("expect: 12 hex digits, but got %s", id)
+	}
+
+	if other := StatementID("SELECT *\n  FROM \"users\"   WHERE id = $1 "); other != id {
+		t.Errorf("expect: %s, but got %s", id, other)
+	}
+
+	stmt, err := GenerateSelectOneStatement(testUsersTable(), false, SQLOptions{StatementIDComment: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "/* id:" + id + ` */ SELECT * FROM "users" WHERE id = $1`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	opt := SQLOptions{StatementIDComment: true}
+	generators := map[string]func() (string, error){
+		"update":      func() (string, error) { return GenerateUpdateSQL(testUsersTable(), false, opt) },
+		"hard delete": func() (string, error) { return GenerateHardDeleteSQL(testUsersTable(), false, opt) },
+		"upsert":      func() (string, error) { return GenerateUpsertSQL(testUsersTable(), false, opt) },
+		"select all":  func() (string, error) { return GenerateSelectAllSQL(testUsersTable(), opt) },
+		"page":        func() (string, error) { return GenerateSelectPageSQL(testUsersTable(), false, opt) },
+	}
+	for name, generate := range generators {
+		sql, err := generate()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		body := sql[strings.Index(sql, "*/ ")+3:]
+		if !strings.HasPrefix(sql, "/* id:"+StatementID(body)+" */ ") {
+			t.Errorf("%s expect statement id comment, but got %s", name, sql)
+		}
+	}
+}
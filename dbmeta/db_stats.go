@@ -0,0 +1,30 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteLiteral quote a string literal
+func quoteLiteral(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// GenerateTableStatsSQL generate sql returning the approximate row count (row_estimate) and on disk size including
+// indexes (total_bytes) of the table. The statistics are read from the catalog rather than by scanning the table, so the
+// row count is only as current as the last analyze. Sqlite is not supported.
+func GenerateTableStatsSQL(dbTable DbTableMeta, dialect Dialect) (string, error) {
+	switch dialect.Name() {
+	case "postgres":
+		return fmt.Sprintf("SELECT c.reltuples::bigint AS row_estimate, pg_total_relation_size(c.oid) AS total_bytes FROM pg_class c WHERE c.oid = %s::regclass",
+			quoteLiteral(dialect.QuoteIdentifier(dbTable.TableName()))), nil
+	case "mysql":
+		return fmt.Sprintf("SELECT table_rows AS row_estimate, data_length + index_length AS total_bytes FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = %s",
+			quoteLiteral(dbTable.TableName())), nil
+	case "mssql":
+		return fmt.Sprintf("SELECT SUM(CASE WHEN index_id IN (0, 1) THEN row_count ELSE 0 END) AS row_estimate, SUM(reserved_page_count) * 8192 AS total_bytes FROM sys.dm_db_partition_stats WHERE object_id = OBJECT_ID(%s)",
+			quoteLiteral(dialect.QuoteIdentifier(dbTable.TableName()))), nil
+	default:
+		return "", fmt.Errorf("table %s dialect %s does not support table statistics, cannot generate sql", dbTable.TableName(), dialect.Name())
+	}
+}
@@ -0,0 +1,32 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateTableStatsSQL(t *testing.T) {
+	table := &dbTableMeta{tableName: "user's"}
+
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{DialectPostgres, `SELECT c.reltuples::bigint AS row_estimate, pg_total_relation_size(c.oid) AS total_bytes FROM pg_class c WHERE c.oid = '"user''s"'::regclass`},
+		{DialectMySQL, `SELECT table_rows AS row_estimate, data_length + index_length AS total_bytes FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'user''s'`},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateTableStatsSQL(table, tt.dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.dialect.Name(), err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.dialect.Name(), tt.expected, sql)
+		}
+	}
+
+	_, err := GenerateTableStatsSQL(table, DialectSQLite)
+	if err == nil {
+		t.Errorf("expected error for sqlite")
+	}
+}
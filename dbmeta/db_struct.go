@@ -0,0 +1,106 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// interfaceType type of an empty interface scan destination, accepting any column value
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// goTypes reflect types of the go types of the sql mappings that can be allocated without the generated model imports
+var goTypes = map[string]reflect.Type{
+	"string":          reflect.TypeOf(""),
+	"bool":            reflect.TypeOf(false),
+	"int32":           reflect.TypeOf(int32(0)),
+	"int64":           reflect.TypeOf(int64(0)),
+	"uint32":          reflect.TypeOf(uint32(0)),
+	"uint64":          reflect.TypeOf(uint64(0)),
+	"float32":         reflect.TypeOf(float32(0)),
+	"float64":         reflect.TypeOf(float64(0)),
+	"[]byte":          reflect.TypeOf([]byte(nil)),
+	"time.Time":       reflect.TypeOf(time.Time{}),
+	"sql.NullString":  reflect.TypeOf(sql.NullString{}),
+	"sql.NullBool":    reflect.TypeOf(sql.NullBool{}),
+	"sql.NullInt32":   reflect.TypeOf(sql.NullInt32{}),
+	"sql.NullInt64":   reflect.TypeOf(sql.NullInt64{}),
+	"sql.NullFloat64": reflect.TypeOf(sql.NullFloat64{}),
+}
+
+// SelectColumnsFromStruct return the column names mapped by the fields of a struct, or pointer to a struct, for use
+// with SQLOptions.Columns. The name is taken from the db tag, or the field name if the field is not tagged, unexported
+// fields and fields tagged db:"-" are skipped and the fields of embedded structs are included in place.
+func SelectColumnsFromStruct(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				columns = append(columns, SelectColumnsFromStruct(reflect.Zero(embedded).Interface())...)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.TrimSpace(strings.Split(tag, ",")[0])
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// ProjectionTypes return the go types of the projected columns in projection order, e.g. to allocate the scan
+// destinations of a generic result handler with reflect.New. Nullable columns use their sql.Null type. If no columns are
+// given the types of every table column are returned in table order, matching a * projection. Unknown columns and
+// types that cannot be allocated without the generated model imports are scanned into an interface{}.
+func ProjectionTypes(dbTable DbTableMeta, columns []string) []reflect.Type {
+	if len(columns) == 0 {
+		for _, col := range dbTable.Columns() {
+			columns = append(columns, col.Name())
+		}
+	}
+
+	types := make([]reflect.Type, len(columns))
+	for i, name := range columns {
+		types[i] = interfaceType
+
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			continue
+		}
+
+		goType, err := SQLTypeToGoType(strings.ToLower(col.DatabaseTypeName()), col.Nullable(), false)
+		if err != nil {
+			continue
+		}
+
+		if t, ok := goTypes[goType]; ok {
+			types[i] = t
+		}
+	}
+	return types
+}
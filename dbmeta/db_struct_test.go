@@ -0,0 +1,64 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAudit struct {
+	CreatedAt string `db:"created_at"`
+}
+
+type testUser struct {
+	testAudit
+	ID       int    `db:"id"`
+	Name     string `db:"name,omitempty"`
+	Email    string
+	Password string `db:"-"`
+	internal string
+}
+
+func Test_SelectColumnsFromStruct(t *testing.T) {
+	columns := SelectColumnsFromStruct(&testUser{})
+	expected := "created_at, id, name, Email"
+	if strings.Join(columns, ", ") != expected {
+		t.Errorf("expect: %s, but got %s", expected, strings.Join(columns, ", "))
+	}
+
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Columns: []string{"id", "name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `SELECT id, name FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Columns: columns})
+	if err == nil {
+		t.Errorf("expected error for columns missing from the table")
+	}
+}
+
+func Test_ProjectionTypes(t *testing.T) {
+	err := LoadMappings("../template/mapping.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := testUsersTable()
+
+	types := ProjectionTypes(table, nil)
+	expect := []reflect.Type{reflect.TypeOf(int32(0)), reflect.TypeOf(""), reflect.TypeOf(sql.NullString{})}
+	if !reflect.DeepEqual(types, expect) {
+		t.Errorf("expect: %v, but got %v", expect, types)
+	}
+
+	types = ProjectionTypes(table, []string{"email", "id", "missing"})
+	expect = []reflect.Type{reflect.TypeOf(sql.NullString{}), reflect.TypeOf(int32(0)), interfaceType}
+	if !reflect.DeepEqual(types, expect) {
+		t.Errorf("expect: %v, but got %v", expect, types)
+	}
+}
@@ -0,0 +1,33 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaPlaceholder placeholder of the schema in the sql generated by the template generators, substituted by
+// RenderSchema
+const SchemaPlaceholder = "{{.Schema}}"
+
+// GenerateSelectOneTemplateSQL generate sql for selecting one record from a table in a schema chosen at runtime, e.g.
+// with a schema per tenant. The table is qualified with the SchemaPlaceholder, which must be substituted with
+// RenderSchema before use. Columns and values are parameterized as by GenerateSelectOneSQL.
+func GenerateSelectOneTemplateSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	opt := assureSQLOptions(opts...)
+	opt.schemaTemplate = true
+	return GenerateSelectOneSQL(dbTable, namedParams, opt)
+}
+
+// RenderSchema substitute the SchemaPlaceholder of sql generated by the template generators with the schema, which
+// must be a safe identifier
+func RenderSchema(sql, schema string) (string, error) {
+	err := validateIdentifier("schema", schema)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(sql, SchemaPlaceholder) {
+		return "", fmt.Errorf("sql does not have a schema placeholder %s, cannot render schema", SchemaPlaceholder)
+	}
+	return strings.Replace(sql, SchemaPlaceholder, schema, -1), nil
+}
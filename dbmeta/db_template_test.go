@@ -0,0 +1,39 @@
+package dbmeta
+
+import (
+	"testing"
+)
+
+func Test_GenerateSelectOneTemplateSQL(t *testing.T) {
+	sql, err := GenerateSelectOneTemplateSQL(testUsersTable(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM {{.Schema}}."users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = RenderSchema(sql, "tenant_42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM tenant_42."users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_RenderSchemaInvalid(t *testing.T) {
+	_, err := RenderSchema(`SELECT * FROM {{.Schema}}."users"`, `public"; DROP TABLE users; --`)
+	if err == nil {
+		t.Errorf("expect error for an unsafe schema")
+	}
+
+	_, err = RenderSchema(`SELECT * FROM "users"`, "tenant_42")
+	if err == nil {
+		t.Errorf("expect error for sql without a schema placeholder")
+	}
+}
@@ -0,0 +1,114 @@
+package dbmeta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// treeParentKey validate the parent column is a foreign key of the table referencing its own single primary key,
+// returning the primary key
+func treeParentKey(dbTable DbTableMeta, parentCol string) (ColumnMeta, error) {
+	keys := primaryKeyColumns(dbTable)
+
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("table %s does not have a single primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	if _, ok := findColumn(dbTable, parentCol); !ok {
+		return nil, fmt.Errorf("table %s does not have a parent column %s, cannot generate sql", dbTable.TableName(), parentCol)
+	}
+
+	for _, fk := range dbTable.ForeignKeys() {
+		if fk.RefTable == dbTable.TableName() && len(fk.Columns) == 1 && fk.Columns[0] == parentCol &&
+			len(fk.RefColumns) == 1 && fk.RefColumns[0] == keys[0].Name() {
+			return keys[0], nil
+		}
+	}
+	return nil, fmt.Errorf("table %s parent column %s does not reference the primary key %s, cannot generate sql",
+		dbTable.TableName(), parentCol, keys[0].Name())
+}
+
+// MysqlServerVersion version of the MySQL server the tree sql is generated for, e.g. 5.7.31. MySQL supports recursive
+// CTEs from 8.0, when set to an older version the tree generators refuse mysql tables. Defaults to "", any version.
+var MysqlServerVersion = ""
+
+// recursiveWith opening keyword of a recursive CTE, SQL Server does not use RECURSIVE. Dialects without recursive CTEs
+// are refused, as is mysql when MysqlServerVersion is older than 8.0.
+func recursiveWith(d Dialect) (string, error) {
+	switch d.Name() {
+	case "mysql":
+		major, err := strconv.Atoi(strings.SplitN(MysqlServerVersion, ".", 2)[0])
+		if MysqlServerVersion != "" && (err != nil || major < 8) {
+			return "", fmt.Errorf("mysql %s does not support recursive CTEs, cannot generate sql", MysqlServerVersion)
+		}
+		return "WITH RECURSIVE", nil
+	case "postgres", "sqlite3":
+		return "WITH RECURSIVE", nil
+	case "mssql":
+		return "WITH", nil
+	}
+	return "", fmt.Errorf("dialect %s does not support recursive CTEs, cannot generate sql", d.Name())
+}
+
+// treeParam placeholder of the id of the record the tree generators start from, named after the primary key
+func treeParam(d Dialect, key ColumnMeta, namedParams bool) string {
+	if namedParams {
+		return d.NamedPlaceholder(key.Name())
+	}
+	return d.Placeholder(1)
+}
+
+// GenerateDescendantsSQL generate sql selecting every descendant of the record with the root id in a self referencing
+// tree, e.g. categories with a parent_id, walking down through the children with a recursive CTE. The root record
+// itself is not selected. The parent column must be a foreign key referencing the single primary key of the table and
+// the tree must not contain cycles.
+func GenerateDescendantsSQL(dbTable DbTableMeta, parentCol string, namedParams bool) (string, error) {
+	key, err := treeParentKey(dbTable, parentCol)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	with, err := recursiveWith(d)
+	if err != nil {
+		return "", err
+	}
+
+	param := treeParam(d, key, namedParams)
+	parent := quoteColumn(d, parentCol)
+	table := d.QuoteIdentifier(dbTable.TableName())
+	return fmt.Sprintf("%s descendants AS (SELECT * FROM %s WHERE %s = %s UNION ALL SELECT c.* FROM %s c JOIN descendants p ON c.%s = p.%s) SELECT * FROM descendants",
+		with, table, parent, param, table, parent, quoteColumn(d, key.Name())), nil
+}
+
+// treeDepthColumn name of the column holding the distance of an ancestor from the starting record
+const treeDepthColumn = "depth"
+
+// GenerateAncestorsSQL generate sql selecting every ancestor of the record with the id in a self referencing tree,
+// walking up through the parents to the root with a recursive CTE. The record itself is not selected, each ancestor is
+// selected along with its depth, 1 for the parent, ordered from the parent up to the root. The parent column must be a
+// foreign key referencing the single primary key of the table and the table must not have a depth column.
+func GenerateAncestorsSQL(dbTable DbTableMeta, parentCol string, namedParams bool) (string, error) {
+	key, err := treeParentKey(dbTable, parentCol)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := findColumn(dbTable, treeDepthColumn); ok {
+		return "", fmt.Errorf("table %s already has a column %s, cannot generate sql", dbTable.TableName(), treeDepthColumn)
+	}
+
+	d := DialectForTable(dbTable)
+	with, err := recursiveWith(d)
+	if err != nil {
+		return "", err
+	}
+
+	param := treeParam(d, key, namedParams)
+	id := quoteColumn(d, key.Name())
+	parent := quoteColumn(d, parentCol)
+	table := d.QuoteIdentifier(dbTable.TableName())
+	return fmt.Sprintf("%s ancestors AS (SELECT p.*, 1 AS %s FROM %s p JOIN %s n ON p.%s = n.%s WHERE n.%s = %s UNION ALL SELECT p.*, a.%s + 1 FROM %s p JOIN ancestors a ON p.%s = a.%s) SELECT * FROM ancestors ORDER BY %s",
+		with, treeDepthColumn, table, table, id, parent, id, param, treeDepthColumn, table, id, parent, treeDepthColumn), nil
+}
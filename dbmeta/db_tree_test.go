@@ -0,0 +1,216 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func testCategoriesTable() *dbTableMeta {
+	id := testColumn("id", "INTEGER", 0)
+	id.isPrimaryKey = true
+	return &dbTableMeta{
+		sqlType:     "sqlite3",
+		tableName:   "categories",
+		columns:     []*columnMeta{id, testColumn("parent_id", "INTEGER", 1), testColumn("name", "TEXT", 2)},
+		foreignKeys: []ForeignKey{{Name: "0", Table: "categories", Columns: []string{"parent_id"}, RefTable: "categories", RefColumns: []string{"id"}}},
+	}
+}
+
+func testCategoriesDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ddl := []string{
+		`CREATE TABLE categories (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES categories, name TEXT)`,
+		`INSERT INTO categories VALUES (1, NULL, 'root'), (2, 1, 'a'), (3, 2, 'a1'), (4, 1, 'b'), (5, NULL, 'other')`,
+	}
+	for _, stmt := range ddl {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func testTreeNames(t *testing.T, db *sql.DB, query string, id int) map[string]bool {
+	rows, err := db.Query(query, id)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var id int
+		var parentID sql.NullInt64
+		var name string
+		err = rows.Scan(&id, &parentID, &name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names[name] = true
+	}
+	return names
+}
+
+func Test_GenerateDescendantsSQL(t *testing.T) {
+	db := testCategoriesDB(t)
+	defer db.Close()
+
+	sql, err := GenerateDescendantsSQL(testCategoriesTable(), "parent_id", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `WITH RECURSIVE descendants AS (SELECT * FROM "categories" WHERE parent_id = ? UNION ALL SELECT c.* FROM "categories" c JOIN descendants p ON c.parent_id = p.id) SELECT * FROM descendants`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	names := testTreeNames(t, db, sql, 1)
+	if len(names) != 3 || !names["a"] || !names["a1"] || !names["b"] {
+		t.Errorf("expect: descendants a, a1 and b, but got %v", names)
+	}
+
+	_, err = GenerateDescendantsSQL(testCategoriesTable(), "name", false)
+	if err == nil {
+		t.Errorf("expect error for a parent column that does not reference the primary key")
+	}
+}
+
+func Test_GenerateAncestorsSQL(t *testing.T) {
+	db := testCategoriesDB(t)
+	defer db.Close()
+
+	sql, err := GenerateAncestorsSQL(testCategoriesTable(), "parent_id", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `WITH RECURSIVE ancestors AS (SELECT p.*, 1 AS depth FROM "categories" p JOIN "categories" n ON p.id = n.parent_id WHERE n.id = ? UNION ALL SELECT p.*, a.depth + 1 FROM "categories" p JOIN ancestors a ON p.id = a.parent_id) SELECT * FROM ancestors ORDER BY depth`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	rows, err := db.Query(sql, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var ancestors []string
+	for rows.Next() {
+		var id, depth int
+		var parentID interface{}
+		var name string
+		err = rows.Scan(&id, &parentID, &name, &depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ancestors = append(ancestors, fmt.Sprintf("%s:%d", name, depth))
+	}
+
+	if strings.Join(ancestors, ",") != "a:1,root:2" {
+		t.Errorf("expect: a:1,root:2, but got %v", ancestors)
+	}
+
+	RegisterDialect("legacy", &legacyDialect{})
+	defer unregisterDialect("legacy")
+
+	table := testCategoriesTable()
+	table.sqlType = "legacy"
+	_, err = GenerateAncestorsSQL(table, "parent_id", false)
+	if err == nil {
+		t.Errorf("expect error for a dialect without recursive CTEs")
+	}
+
+	table.sqlType = "mysql"
+	MysqlServerVersion = "5.7.31"
+	defer func() { MysqlServerVersion = "" }()
+	_, err = GenerateAncestorsSQL(table, "parent_id", false)
+	if err == nil {
+		t.Errorf("expect error for mysql 5.7")
+	}
+
+	MysqlServerVersion = "8.0.21"
+	sql, err = GenerateAncestorsSQL(table, "parent_id", false)
+	if err != nil || !strings.HasPrefix(sql, "WITH RECURSIVE ancestors") {
+		t.Errorf("expect recursive CTE for mysql 8.0, but got %s %v", sql, err)
+	}
+}
+
+func Test_TreeSQLReservedColumns(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ddl := []string{
+		`CREATE TABLE nodes ("key" INTEGER PRIMARY KEY, "order" INTEGER REFERENCES nodes)`,
+		`INSERT INTO nodes VALUES (1, NULL), (2, 1), (3, 2)`,
+	}
+	for _, stmt := range ddl {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := testColumn("key", "INTEGER", 0)
+	key.isPrimaryKey = true
+	nodes := &dbTableMeta{
+		sqlType:     "sqlite3",
+		tableName:   "nodes",
+		columns:     []*columnMeta{key, testColumn("order", "INTEGER", 1)},
+		foreignKeys: []ForeignKey{{Name: "0", Table: "nodes", Columns: []string{"order"}, RefTable: "nodes", RefColumns: []string{"key"}}},
+	}
+
+	descendants, err := GenerateDescendantsSQL(nodes, "order", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ancestors, err := GenerateAncestorsSQL(nodes, "order", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tree := range []string{descendants, ancestors} {
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM ("+tree+")", 2).Scan(&count)
+		if err != nil {
+			t.Fatalf("%s: %v", tree, err)
+		}
+		if count != 1 {
+			t.Errorf("expect: 1 record, but got %d for %s", count, tree)
+		}
+	}
+
+	nodes.sqlType = "mssql"
+	for _, generate := range []func(DbTableMeta, string, bool) (string, error){GenerateDescendantsSQL, GenerateAncestorsSQL} {
+		tree, err := generate(nodes, "order", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(tree, " = @key UNION ALL") || !strings.Contains(tree, ".[order]") {
+			t.Errorf("expect the @key parameter and the quoted [order] column, but got %s", tree)
+		}
+	}
+}
+
+// legacyDialect dialect without recursive CTEs
+type legacyDialect struct {
+	sqliteDialect
+}
+
+// Name name of the dialect
+func (d *legacyDialect) Name() string {
+	return "legacy"
+}
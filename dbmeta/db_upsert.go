@@ -0,0 +1,422 @@
+package dbmeta
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var stringLiteralRegex = regexp.MustCompile(`'(?:[^']|'')*'`)
+var qualifiedColumnRegex = regexp.MustCompile(`("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)\.("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)`)
+
+// GenerateUpsertSQL generate sql for an insert that updates the existing record when the conflict target inferred by
+// InferConflictTarget for SQLOptions.UpsertConflict conflicts, the primary key by default. All included columns are
+// inserted, a surrogate primary key is left to the database when conflicting on a unique index, the conflict update
+// sets SQLOptions.UpsertUpdateColumns or every non primary key column if not set. Postgres and Sqlite 3.24+ use ON
+// CONFLICT, MySQL uses ON DUPLICATE KEY UPDATE and SQL Server a MERGE, which cannot insert an identity conflict key.
+// SQLOptions.UpsertUpdateWhere is appended to the conflict update as its WHERE guard, which MySQL and SQL Server do not
+// support. SQLOptions.UpsertInsertOnlyColumns are inserted but left out of the conflict update. The upserted record
+// returns SQLOptions.Returning, note that a DO NOTHING upsert does not return a row when the record already exists, as
+// nothing was written.
+func GenerateUpsertSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	primaryCnt := PrimaryKeyCount(dbTable)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return "", err
+	}
+
+	err = validateUniqueColumns(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+
+	target, err := InferConflictTarget(dbTable, opt.UpsertConflict)
+	if err != nil {
+		return "", err
+	}
+
+	// SQL Server refuses explicit values for an IDENTITY column unless IDENTITY_INSERT is on, so the MERGE cannot insert
+	// an auto increment conflict key
+	if d.Name() == "mssql" {
+		for _, name := range target.Columns {
+			col, ok := findColumn(dbTable, name)
+			if ok && col.IsAutoIncrement() {
+				return "", fmt.Errorf("table %s conflict key %s is an identity column, dialect %s cannot insert it, cannot generate sql",
+					dbTable.TableName(), name, d.Name())
+			}
+		}
+	}
+
+	// the conflict key is never overwritten, a surrogate key is left to the database when conflicting on a unique index
+	conflictCols := target.Columns
+	isKey := func(col ColumnMeta) bool {
+		_, ok := FindInSlice(conflictCols, col.Name())
+		return ok || col.IsPrimaryKey()
+	}
+
+	var cols, values, updateCols []string
+	pos := 1
+	for _, col := range dbTable.Columns() {
+		if col.IsPrimaryKey() && target.Index != "" {
+			continue
+		}
+
+		if !isKey(col) && !opt.includeColumn(col) {
+			continue
+		}
+
+		param := d.Placeholder(pos)
+		if namedParams {
+			param = d.NamedPlaceholder(col.Name())
+		}
+		cols = append(cols, col.Name())
+		values = append(values, param)
+		pos++
+
+		_, insertOnly := FindInSlice(opt.UpsertInsertOnlyColumns, col.Name())
+		if !isKey(col) && !insertOnly && len(opt.UpsertUpdateColumns) == 0 {
+			updateCols = append(updateCols, col.Name())
+		}
+	}
+
+	for _, name := range opt.UpsertInsertOnlyColumns {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return "", fmt.Errorf("table %s does not have an insert only column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		if isKey(col) {
+			return "", fmt.Errorf("table %s insert only column %s is part of the conflict key, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		if _, ok := FindInSlice(opt.UpsertUpdateColumns, name); ok {
+			return "", fmt.Errorf("table %s insert only column %s is also an update column, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+
+	for _, name := range opt.UpsertUpdateColumns {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return "", fmt.Errorf("table %s does not have an update column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		if col.IsPrimaryKey() {
+			return "", fmt.Errorf("table %s update column %s is part of the primary key, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		if isKey(col) {
+			return "", fmt.Errorf("table %s update column %s is part of the conflict key, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		if _, ok := FindInSlice(cols, name); !ok {
+			return "", fmt.Errorf("table %s update column %s is not an inserted column, cannot generate sql", dbTable.TableName(), name)
+		}
+		updateCols = append(updateCols, name)
+	}
+
+	if opt.UpsertUpdateWhere != "" {
+		if d.Name() == "mysql" || d.Name() == "mssql" {
+			return "", fmt.Errorf("table %s upsert update guard is not supported for %s, cannot generate sql", dbTable.TableName(), d.Name())
+		}
+		if len(updateCols) == 0 {
+			return "", fmt.Errorf("table %s upsert update guard requires columns to update, cannot generate sql", dbTable.TableName())
+		}
+
+		err := validateUpsertUpdateWhere(d, dbTable, opt.UpsertUpdateWhere)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	returning, err := opt.returningColumns(d, dbTable, "INSERTED")
+	if err != nil {
+		return "", err
+	}
+
+	// reserved word columns are quoted the same way in the column list, conflict target and both sides of the update
+	quoted := make([]string, len(cols))
+	for i, name := range cols {
+		quoted[i] = quoteColumn(d, name)
+	}
+	conflictTarget := make([]string, len(conflictCols))
+	for i, name := range conflictCols {
+		conflictTarget[i] = quoteColumn(d, name)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		d.QuoteIdentifier(dbTable.TableName()), strings.Join(quoted, ", "), strings.Join(values, ", "))
+
+	buf := bytes.Buffer{}
+	switch d.Name() {
+	case "mssql":
+		buf.WriteString(mergeUpsertSQL(d, dbTable, quoted, values, conflictTarget, updateCols, returning))
+	case "mysql":
+		buf.WriteString(insert)
+		if len(updateCols) == 0 {
+			updateCols = conflictCols[:1]
+		}
+
+		var sets []string
+		for _, name := range updateCols {
+			name = quoteColumn(d, name)
+			sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", name, name))
+		}
+		buf.WriteString(fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s", strings.Join(sets, ", ")))
+	default:
+		buf.WriteString(insert)
+		buf.WriteString(fmt.Sprintf(" ON CONFLICT (%s)", strings.Join(conflictTarget, ", ")))
+		if len(updateCols) == 0 {
+			buf.WriteString(" DO NOTHING")
+			break
+		}
+
+		// sqlite documents the proposed row as the lower case excluded table, both dialects accept either case
+		excluded := "EXCLUDED"
+		if d.Name() == "sqlite3" {
+			excluded = "excluded"
+		}
+
+		var sets []string
+		for _, name := range updateCols {
+			name = quoteColumn(d, name)
+			sets = append(sets, fmt.Sprintf("%s = %s.%s", name, excluded, name))
+		}
+		buf.WriteString(fmt.Sprintf(" DO UPDATE SET %s", strings.Join(sets, ", ")))
+		if opt.UpsertUpdateWhere != "" {
+			buf.WriteString(fmt.Sprintf(" WHERE %s", opt.UpsertUpdateWhere))
+		}
+	}
+
+	if len(returning) > 0 && d.Name() != "mssql" {
+		buf.WriteString(fmt.Sprintf(" RETURNING %s", strings.Join(returning, ", ")))
+	}
+
+	return opt.annotated(dbTable, "upsert", buf.String()), nil
+}
+
+// ConflictMode how an upsert infers its conflict target
+type ConflictMode int
+
+const (
+	// ConflictPrimaryKey conflict on the primary key
+	ConflictPrimaryKey ConflictMode = iota
+	// ConflictUniqueForSurrogateKey conflict on the single unique index of the table when the primary key is a pure
+	// surrogate auto increment key, as callers do not know the key of the record they upsert. Falls back to the primary
+	// key otherwise.
+	ConflictUniqueForSurrogateKey
+)
+
+// ConflictTarget conflict target of an upsert and why it was chosen
+type ConflictTarget struct {
+	// Columns conflict columns, in key or index order
+	Columns []string
+
+	// Index name of the unique index conflicted on, empty if conflicting on the primary key
+	Index string
+
+	// Reason human readable reason the target was chosen, e.g. to log the inference
+	Reason string
+}
+
+// InferConflictTarget infer the conflict target of an upsert of the table for the mode. The primary key is preferred,
+// ConflictUniqueForSurrogateKey conflicts on the unique index instead when the table has a single unique index and a
+// single auto increment primary key column.
+func InferConflictTarget(dbTable DbTableMeta, mode ConflictMode) (ConflictTarget, error) {
+	keys := primaryKeyColumns(dbTable)
+	if len(keys) == 0 {
+		return ConflictTarget{}, fmt.Errorf("table %s does not have a primary key, cannot infer the conflict target", dbTable.TableName())
+	}
+
+	target := ConflictTarget{Reason: "primary key"}
+	for _, col := range keys {
+		target.Columns = append(target.Columns, col.Name())
+	}
+
+	if mode != ConflictUniqueForSurrogateKey {
+		return target, nil
+	}
+
+	if len(keys) > 1 || !keys[0].IsAutoIncrement() {
+		target.Reason = "primary key, the primary key is not a single auto increment surrogate key"
+		return target, nil
+	}
+
+	var unique []Index
+	for _, idx := range dbTable.Indexes() {
+		if idx.Unique && len(idx.Columns) > 0 && !(len(idx.Columns) == 1 && idx.Columns[0] == keys[0].Name()) {
+			unique = append(unique, idx)
+		}
+	}
+
+	if len(unique) != 1 {
+		target.Reason = fmt.Sprintf("primary key, the table has %d unique indexes instead of one", len(unique))
+		return target, nil
+	}
+
+	for _, name := range unique[0].Columns {
+		if _, ok := findColumn(dbTable, name); !ok {
+			return ConflictTarget{}, fmt.Errorf("table %s does not have a column %s of unique index %s, cannot infer the conflict target",
+				dbTable.TableName(), name, unique[0].Name)
+		}
+	}
+
+	return ConflictTarget{
+		Columns: append([]string{}, unique[0].Columns...),
+		Index:   unique[0].Name,
+		Reason:  fmt.Sprintf("unique index %s, the primary key %s is an auto increment surrogate key", unique[0].Name, keys[0].Name()),
+	}, nil
+}
+
+// mergeUpsertSQL SQL Server upsert, a MERGE of the parameter row into the table matched on the conflict target. The
+// table is merged WITH (HOLDLOCK) so concurrent upserts of the same key do not both insert, and the statement is
+// terminated with a semicolon as required by MERGE. The returning columns are output after the merge clauses.
+func mergeUpsertSQL(d Dialect, dbTable DbTableMeta, cols, values, conflictTarget, updateCols, returning []string) string {
+	var on []string
+	for _, name := range conflictTarget {
+		on = append(on, fmt.Sprintf("target.%s = source.%s", name, name))
+	}
+
+	sourceCols := make([]string, len(cols))
+	for i, name := range cols {
+		sourceCols[i] = "source." + name
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("MERGE INTO %s WITH (HOLDLOCK) AS target USING (VALUES (%s)) AS source (%s) ON %s",
+		d.QuoteIdentifier(dbTable.TableName()), strings.Join(values, ", "), strings.Join(cols, ", "), strings.Join(on, " AND ")))
+
+	if len(updateCols) > 0 {
+		var sets []string
+		for _, name := range updateCols {
+			name = quoteColumn(d, name)
+			sets = append(sets, fmt.Sprintf("%s = source.%s", name, name))
+		}
+		buf.WriteString(fmt.Sprintf(" WHEN MATCHED THEN UPDATE SET %s", strings.Join(sets, ", ")))
+	}
+
+	buf.WriteString(fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", strings.Join(cols, ", "), strings.Join(sourceCols, ", ")))
+	if len(returning) > 0 {
+		buf.WriteString(fmt.Sprintf(" OUTPUT %s", strings.Join(returning, ", ")))
+	}
+	buf.WriteString(";")
+	return buf.String()
+}
+
+// validateUpsertUpdateWhere check the qualified column references of the upsert update guard. References must be
+// qualified with EXCLUDED or the table name and name a column of the table, qualified references in string literals
+// are ignored.
+func validateUpsertUpdateWhere(d Dialect, dbTable DbTableMeta, where string) error {
+	// blank out string literals so their content is not mistaken for column references
+	where = stringLiteralRegex.ReplaceAllStringFunc(where, func(s string) string { return strings.Repeat(" ", len(s)) })
+
+	refs := qualifiedColumnRegex.FindAllStringSubmatch(where, -1)
+	if len(refs) == 0 {
+		return fmt.Errorf("table %s upsert update guard %q does not reference a column, cannot generate sql", dbTable.TableName(), where)
+	}
+
+	for _, ref := range refs {
+		qualifier, name := unquoteIdentifier(ref[1]), unquoteIdentifier(ref[2])
+		if !strings.EqualFold(ref[1], "EXCLUDED") && qualifier != dbTable.TableName() {
+			return fmt.Errorf("table %s upsert update guard reference %s must be qualified with EXCLUDED or %s, cannot generate sql",
+				dbTable.TableName(), ref[0], d.QuoteIdentifier(dbTable.TableName()))
+		}
+
+		if _, ok := findColumn(dbTable, name); !ok {
+			return fmt.Errorf("table %s does not have an upsert update guard column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+	return nil
+}
+
+// unquoteIdentifier strip the double quotes of a quoted identifier
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return strings.Replace(name[1:len(name)-1], `""`, `"`, -1)
+	}
+	return name
+}
+
+// GenerateInsertIfAbsentSQL generate sql inserting a record unless a record with the same primary key exists, using
+// INSERT ... SELECT ... WHERE NOT EXISTS for dialects or versions without ON CONFLICT. The primary keys must be inserted
+// so they can be matched, auto increment keys are not supported. Numbered and named placeholders are reused by the
+// NOT EXISTS predicate, ? placeholders bind the keys again after the values as listed in the statement Params. Postgres
+// values are cast to the column types as the select list does not take the types of the inserted columns.
+func GenerateInsertIfAbsentSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (*GeneratedStatement, error) {
+	keys := primaryKeyColumns(dbTable)
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+
+	cols, err := insertColumns(dbTable, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(cols))
+	values := make([]string, len(cols))
+	positions := make(map[string]string)
+	var params []StatementParam
+	for i, col := range cols {
+		param := d.Placeholder(i + 1)
+		if namedParams {
+			param = d.NamedPlaceholder(col.Name())
+		}
+		positions[col.Name()] = param
+
+		names[i] = col.Name()
+		values[i] = param
+		if d.Name() == "postgres" {
+			values[i] = param + "::" + paramType(col)
+		}
+		params = append(params, newStatementParam(col.Name(), col))
+	}
+
+	repeatParams := !namedParams && d.Placeholder(1) == d.Placeholder(2)
+
+	var predicates []string
+	for _, key := range keys {
+		param, ok := positions[key.Name()]
+		if !ok {
+			return nil, fmt.Errorf("table %s primary key %s is not inserted, cannot generate insert if absent sql", dbTable.TableName(), key.Name())
+		}
+
+		predicates = append(predicates, fmt.Sprintf("%s = %s", key.Name(), param))
+		if repeatParams {
+			params = append(params, newStatementParam(key.Name(), key))
+		}
+	}
+
+	// MySQL before 8.0 requires a FROM clause for a select with a WHERE, DUAL is its dummy table
+	from := ""
+	if d.Name() == "mysql" {
+		from = " FROM DUAL"
+	}
+
+	table := d.QuoteIdentifier(dbTable.TableName())
+	sql := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s%s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+		table, strings.Join(names, ", "), strings.Join(values, ", "), from, table, strings.Join(predicates, " AND "))
+
+	return &GeneratedStatement{
+		Name:   StatementName(dbTable, "insert_if_absent", params),
+		SQL:    opt.annotated(dbTable, "insert_if_absent", sql),
+		Params: params,
+	}, nil
+}
@@ -0,0 +1,394 @@
+package dbmeta
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func Test_GenerateUpsertSQL(t *testing.T) {
+	sql, err := GenerateUpsertSQL(testUsersTable(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (id, name, email) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateUpsertSQL(testUsersTable(), false, SQLOptions{UpsertUpdateColumns: []string{"email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "users" (id, name, email) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateUpsertSQL(testUsersTable(), false, SQLOptions{UpsertUpdateColumns: []string{"missing"}})
+	if err == nil {
+		t.Errorf("expect error for unknown update column")
+	}
+}
+
+func Test_GenerateUpsertSQLUpdateWhere(t *testing.T) {
+	opt := SQLOptions{UpsertUpdateWhere: `EXCLUDED.name <> "users".name AND users.email IS DISTINCT FROM 'a.b'`}
+	sql, err := GenerateUpsertSQL(testUsersTable(), false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (id, name, email) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email WHERE EXCLUDED.name <> "users".name AND users.email IS DISTINCT FROM 'a.b'`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []string{
+		`EXCLUDED.updated_at > "users".updated_at`,
+		`EXCLUDED.name <> orders.name`,
+		`true`,
+	}
+	for _, where := range invalid {
+		_, err = GenerateUpsertSQL(testUsersTable(), false, SQLOptions{UpsertUpdateWhere: where})
+		if err == nil {
+			t.Errorf("expect error for update guard %s", where)
+		}
+	}
+
+	mysqlTable := testUsersTable()
+	mysqlTable.sqlType = "mysql"
+	_, err = GenerateUpsertSQL(mysqlTable, false, opt)
+	if err == nil {
+		t.Errorf("expect error for mysql update guard")
+	}
+}
+
+func Test_GenerateInsertIfAbsentSQL(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE tags (code TEXT NOT NULL, lang TEXT NOT NULL, label TEXT, PRIMARY KEY (code, lang))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := &dbTableMeta{
+		sqlType:   "sqlite3",
+		tableName: "tags",
+		columns:   []*columnMeta{testColumn("code", "TEXT", 0), testColumn("lang", "TEXT", 1), testColumn("label", "TEXT", 2)},
+	}
+	tags.columns[0].isPrimaryKey = true
+	tags.columns[1].isPrimaryKey = true
+
+	stmt, err := GenerateInsertIfAbsentSQL(tags, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "tags" (code, lang, label) SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM "tags" WHERE code = ? AND lang = ?)`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if len(stmt.Params) != 5 || stmt.Params[3].Column != "code" {
+		t.Errorf("expect keys to be bound again, but got %v", stmt.Params)
+	}
+
+	for _, label := range []string{"first", "second"} {
+		_, err = db.Exec(stmt.SQL, "go", "en", label, "go", "en")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var label string
+	err = db.QueryRow(`SELECT label FROM tags WHERE code = 'go'`).Scan(&label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label != "first" {
+		t.Errorf("expect: first, but got %s", label)
+	}
+
+	tags.sqlType = "postgres"
+	stmt, err = GenerateInsertIfAbsentSQL(tags, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "tags" (code, lang, label) SELECT $1::text, $2::text, $3::text WHERE NOT EXISTS (SELECT 1 FROM "tags" WHERE code = $1 AND lang = $2)`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	tags.sqlType = "mysql"
+	stmt, err = GenerateInsertIfAbsentSQL(tags, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "INSERT INTO `tags` (code, lang, label) SELECT ?, ?, ? FROM DUAL WHERE NOT EXISTS (SELECT 1 FROM `tags` WHERE code = ? AND lang = ?)"
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	var bound []string
+	for _, param := range stmt.Params {
+		bound = append(bound, param.Column)
+	}
+	if strings.Join(bound, ", ") != "code, lang, label, code, lang" {
+		t.Errorf("expect: code, lang, label, code, lang, but got %v", bound)
+	}
+
+	_, err = GenerateInsertIfAbsentSQL(testUsersTable(), false)
+	if err == nil {
+		t.Errorf("expect error for auto increment primary key")
+	}
+}
+
+func Test_GenerateUpsertSQLInsertOnlyColumns(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("created_at", "TIMESTAMPTZ", 3), testColumn("updated_at", "TIMESTAMPTZ", 4))
+
+	sql, err := GenerateUpsertSQL(table, false, SQLOptions{UpsertInsertOnlyColumns: []string{"created_at"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (id, name, email, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email, updated_at = EXCLUDED.updated_at`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []SQLOptions{
+		{UpsertInsertOnlyColumns: []string{"missing"}},
+		{UpsertInsertOnlyColumns: []string{"id"}},
+		{UpsertInsertOnlyColumns: []string{"created_at"}, UpsertUpdateColumns: []string{"created_at"}},
+	}
+	for _, opt := range invalid {
+		_, err = GenerateUpsertSQL(table, false, opt)
+		if err == nil {
+			t.Errorf("expect error for upsert options %v", opt)
+		}
+	}
+}
+
+func Test_GenerateUpsertSQLReservedWordColumns(t *testing.T) {
+	tenantID := testColumn("tenant_id", "INT4", 0)
+	tenantID.isPrimaryKey = true
+	key := testColumn("key", "VARCHAR", 1)
+	key.isPrimaryKey = true
+	settings := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "settings",
+		columns:   []*columnMeta{tenantID, key, testColumn("order", "INT4", 2), testColumn("value", "TEXT", 3)},
+	}
+
+	sql, err := GenerateUpsertSQL(settings, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "settings" (tenant_id, "key", "order", value) VALUES ($1, $2, $3, $4) ON CONFLICT (tenant_id, "key") DO UPDATE SET "order" = EXCLUDED."order", value = EXCLUDED.value`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	settings.sqlType = "mysql"
+	sql, err = GenerateUpsertSQL(settings, false, SQLOptions{UpsertUpdateColumns: []string{"order"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "INSERT INTO `settings` (tenant_id, `key`, `order`, value) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `order` = VALUES(`order`)"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateUpsertSQLReturning(t *testing.T) {
+	sql, err := GenerateUpsertSQL(testUsersTable(), false, SQLOptions{Returning: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (id, name, email) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email RETURNING *`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	// an existing record is left as is and no row is returned
+	opt := SQLOptions{Returning: []string{"id"}, Include: func(col ColumnMeta) bool { return false }}
+	sql, err = GenerateUpsertSQL(testUsersTable(), false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "users" (id) VALUES ($1) ON CONFLICT (id) DO NOTHING RETURNING id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table := testUsersTable()
+	table.sqlType = "mysql"
+	_, err = GenerateUpsertSQL(table, false, SQLOptions{Returning: []string{"*"}})
+	if err == nil {
+		t.Errorf("expect error for returning on mysql")
+	}
+}
+
+func Test_InferConflictTarget(t *testing.T) {
+	table := testUsersTable()
+	table.indexes = []Index{{Name: "users_email_key", Columns: []string{"email"}, Unique: true}}
+
+	target, err := InferConflictTarget(table, ConflictPrimaryKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Index != "" || len(target.Columns) != 1 || target.Columns[0] != "id" {
+		t.Errorf("expect primary key target, but got %v", target)
+	}
+
+	target, err = InferConflictTarget(table, ConflictUniqueForSurrogateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Index != "users_email_key" || len(target.Columns) != 1 || target.Columns[0] != "email" {
+		t.Errorf("expect unique index target, but got %v", target)
+	}
+
+	sql, err := GenerateUpsertSQL(table, false, SQLOptions{UpsertConflict: ConflictUniqueForSurrogateKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (name, email) VALUES ($1, $2) ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	// a natural primary key is preferred over the unique index
+	table.columns[0].isAutoIncrement = false
+	target, err = InferConflictTarget(table, ConflictUniqueForSurrogateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Index != "" || target.Columns[0] != "id" {
+		t.Errorf("expect primary key target, but got %v", target)
+	}
+
+	// more than one unique index is ambiguous
+	table.columns[0].isAutoIncrement = true
+	table.indexes = append(table.indexes, Index{Name: "users_name_key", Columns: []string{"name"}, Unique: true})
+	target, err = InferConflictTarget(table, ConflictUniqueForSurrogateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Index != "" || target.Columns[0] != "id" {
+		t.Errorf("expect primary key target, but got %v", target)
+	}
+}
+
+func Test_GenerateUpsertSQLSqlite(t *testing.T) {
+	id := testColumn("id", "INTEGER", 0)
+	id.isPrimaryKey = true
+	name := testColumn("name", "TEXT", 1)
+	order := testColumn("order", "INTEGER", 2)
+	table := &dbTableMeta{sqlType: "sqlite3", tableName: "items", columns: []*columnMeta{id, name, order}}
+
+	query, err := GenerateUpsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "items" (id, name, "order") VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = excluded.name, "order" = excluded."order"`
+	if query != expected {
+		t.Errorf("expect: %s, but got %s", expected, query)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, "order" INTEGER)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]interface{}{{1, "first", 1}, {1, "second", 2}} {
+		_, err = db.Exec(query, args...)
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	var count, gotOrder int
+	var gotName string
+	err = db.QueryRow(`SELECT COUNT(*), MAX(name), MAX("order") FROM items`).Scan(&count, &gotName, &gotOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || gotName != "second" || gotOrder != 2 {
+		t.Errorf("expect the record to be updated, but got %d %s %d", count, gotName, gotOrder)
+	}
+}
+
+func Test_GenerateUpsertSQLMssql(t *testing.T) {
+	table := testUsersTable()
+	table.sqlType = "mssql"
+
+	_, err := GenerateUpsertSQL(table, false)
+	if err == nil {
+		t.Errorf("expect error for an identity conflict key on mssql")
+	}
+
+	// the identity key is left to the database when conflicting on a unique index
+	table.indexes = []Index{{Name: "users_email_key", Columns: []string{"email"}, Unique: true}}
+	sql, err := GenerateUpsertSQL(table, false, SQLOptions{UpsertConflict: ConflictUniqueForSurrogateKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "MERGE INTO [users] WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2)) AS source (name, email) ON target.email = source.email" +
+		" WHEN MATCHED THEN UPDATE SET name = source.name" +
+		" WHEN NOT MATCHED THEN INSERT (name, email) VALUES (source.name, source.email);"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.columns[0].isAutoIncrement = false
+	sql, err = GenerateUpsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "MERGE INTO [users] WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3)) AS source (id, name, email) ON target.id = source.id" +
+		" WHEN MATCHED THEN UPDATE SET name = source.name, email = source.email" +
+		" WHEN NOT MATCHED THEN INSERT (id, name, email) VALUES (source.id, source.name, source.email);"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateUpsertSQL(table, false, SQLOptions{Returning: []string{"id"}, UpsertUpdateColumns: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "MERGE INTO [users] WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3)) AS source (id, name, email) ON target.id = source.id" +
+		" WHEN MATCHED THEN UPDATE SET name = source.name" +
+		" WHEN NOT MATCHED THEN INSERT (id, name, email) VALUES (source.id, source.name, source.email) OUTPUT INSERTED.id;"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateUpsertSQL(table, false, SQLOptions{UpsertUpdateWhere: `EXCLUDED.name <> "users".name`})
+	if err == nil {
+		t.Errorf("expect error for update guard on mssql")
+	}
+}
@@ -2,11 +2,48 @@ package dbmeta
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 )
 
+// ErrNothingToUpdate the table does not have any non primary key columns to set in an update
+var ErrNothingToUpdate = errors.New("no non primary key columns to update")
+
+// ErrNoInsertableColumns every column of the table is left to the database, e.g. auto increment or generated, and
+// SQLOptions.ErrorOnEmptyInsert is set
+var ErrNoInsertableColumns = errors.New("no insertable columns")
+
+// ErrDuplicateColumn the table meta data lists a column name more than once, e.g. from a view scanner
+var ErrDuplicateColumn = errors.New("duplicate column name")
+
+// validateUniqueColumns check every column name of the table is listed once, so the column lists of a statement align
+// with its placeholders
+func validateUniqueColumns(dbTable DbTableMeta) error {
+	seen := make(map[string]bool)
+	for _, col := range dbTable.Columns() {
+		if seen[col.Name()] {
+			return fmt.Errorf("table %s column %s, cannot generate sql: %w", dbTable.TableName(), col.Name(), ErrDuplicateColumn)
+		}
+		seen[col.Name()] = true
+	}
+	return nil
+}
+
 // PrimaryKeyCount return the number of primary keys in table
 func PrimaryKeyCount(dbTable DbTableMeta) int {
+	// fast path for the loaded table meta, counting the keys without copying the columns
+	if m, ok := dbTable.(*dbTableMeta); ok {
+		primaryKeys := 0
+		for _, col := range m.columns {
+			if col.isPrimaryKey {
+				primaryKeys++
+			}
+		}
+		return primaryKeys
+	}
+
 	primaryKeys := 0
 	for _, col := range dbTable.Columns() {
 		if col.IsPrimaryKey() {
@@ -16,6 +53,28 @@ func PrimaryKeyCount(dbTable DbTableMeta) int {
 	return primaryKeys
 }
 
+// primaryKeyColumns return the primary key columns of the table in table order, so the generators scan the columns for
+// the keys once. A table with a single primary key, by far the most common shape, only allocates the one column.
+func primaryKeyColumns(dbTable DbTableMeta) []ColumnMeta {
+	if m, ok := dbTable.(*dbTableMeta); ok {
+		var keys []ColumnMeta
+		for _, col := range m.columns {
+			if col.isPrimaryKey {
+				keys = append(keys, col)
+			}
+		}
+		return keys
+	}
+
+	var keys []ColumnMeta
+	for _, col := range dbTable.Columns() {
+		if col.IsPrimaryKey() {
+			keys = append(keys, col)
+		}
+	}
+	return keys
+}
+
 // PrimaryKeyNames return the list of primary key names
 func PrimaryKeyNames(dbTable DbTableMeta) []string {
 	primaryKeyNames := make([]string, 0)
@@ -38,253 +97,1321 @@ func NonPrimaryKeyNames(dbTable DbTableMeta) []string {
 	return primaryKeyNames
 }
 
-// GenerateHardDeleteSQL generate sql for a delete
-func GenerateHardDeleteSQL(dbTable DbTableMeta, namedParams bool) (string, error) {
-	primaryCnt := PrimaryKeyCount(dbTable)
+// softDeleteColumnNames names of columns used to flag a record as soft deleted
+var softDeleteColumnNames = []string{"deleted_at", "DeletedAt"}
+
+// defaultDeletedByColumn name of the column recording who soft deleted a record
+const defaultDeletedByColumn = "deleted_by"
+
+// findSoftDeleteColumn lookup the soft delete column of the table, SQLOptions.SoftDeleteColumn if set
+func findSoftDeleteColumn(dbTable DbTableMeta, opt SQLOptions) (ColumnMeta, bool) {
+	if opt.SoftDeleteColumn != "" {
+		return findColumn(dbTable, opt.SoftDeleteColumn)
+	}
+
+	for _, name := range softDeleteColumnNames {
+		col, ok := findColumn(dbTable, name)
+		if ok {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
+// softDeleteFilter predicate to exclude soft deleted records, empty if the table does not have a soft delete column
+// or deleted records are included
+func softDeleteFilter(dbTable DbTableMeta, opt SQLOptions) string {
+	if opt.IncludeDeleted {
+		return ""
+	}
+
+	col, ok := findSoftDeleteColumn(dbTable, opt)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" AND %s IS NULL", opt.columnRef(col.Name()))
+}
+
+// scopeFilter where clause of the selects without key predicates, matching the tenant bound at pos when the records are
+// tenant scoped and excluding soft deleted records, empty if neither applies
+func scopeFilter(d Dialect, dbTable DbTableMeta, opt SQLOptions, tenantCol ColumnMeta, namedParams bool, pos int) string {
+	var predicates []string
+	if tenantCol != nil {
+		where := newKeyWhere(d, opt, namedParams, pos)
+		where.addTenant(tenantCol)
+		predicates = append(predicates, where.String())
+	}
+	if softDelete := softDeleteFilter(dbTable, opt); softDelete != "" {
+		predicates = append(predicates, strings.TrimPrefix(softDelete, " AND "))
+	}
+
+	if len(predicates) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(predicates, " AND ")
+}
+
+// GenerateHardDeleteSQL generate sql for a delete, the deleted record is returned when SQLOptions.Returning is set
+func GenerateHardDeleteSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	keys := primaryKeyColumns(dbTable)
+	primaryCnt := len(keys)
 
 	if primaryCnt == 0 {
 		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
 	}
 
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	returning, err := opt.returningColumns(d, dbTable, "DELETED")
+	if err != nil {
+		return "", err
+	}
+
 	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`DELETE FROM "%s" where`, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("DELETE FROM %s", d.QuoteIdentifier(dbTable.TableName())))
+	if len(returning) > 0 && d.Name() == "mssql" {
+		buf.WriteString(fmt.Sprintf(" OUTPUT %s", strings.Join(returning, ", ")))
+	}
+	buf.WriteString(" where")
 
-	addedKey := 1
-	for _, col := range dbTable.Columns() {
-		if col.IsPrimaryKey() {
-			param := fmt.Sprintf("$%d", addedKey)
-			if namedParams {
-				param = fmt.Sprintf("@%s_%d", col.Name(), addedKey)
-			}
-			buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
-			addedKey++
+	for i, col := range keys {
+		if i > 0 {
+			buf.WriteString(" AND")
+		}
 
-			if addedKey < primaryCnt {
-				buf.WriteString(" AND")
-			}
+		param := opt.castParam(col, d.Placeholder(i+1))
+		if namedParams {
+			param = d.NamedPlaceholder(fmt.Sprintf("%s_%d", col.Name(), i+1))
 		}
+		buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
 	}
 
-	return buf.String(), nil
+	if len(returning) > 0 && d.Name() != "mssql" {
+		buf.WriteString(fmt.Sprintf(" RETURNING %s", strings.Join(returning, ", ")))
+	}
+	return opt.annotated(dbTable, "hard_delete", buf.String()), nil
 }
 
-// GenerateSoftDeleteSQL generate sql for a soft delete (update)
-func GenerateSoftDeleteSQL(dbTable DbTableMeta, namedParams bool) (string, error) {
-	primaryCnt := PrimaryKeyCount(dbTable)
-	// nonPrimaryCnt := len(dbTable.Columns()) - primaryCnt
+// GenerateDeleteWithAuditSQL generate sql deleting a record by primary key and copying it into the audit table in the
+// same statement, using a data modifying CTE: WITH deleted AS (DELETE ... RETURNING *) INSERT INTO audit ... SELECT ...
+// FROM deleted. The audit table must have every column of the table, extra audit columns are left to their default.
+// Only Postgres supports data modifying CTEs.
+func GenerateDeleteWithAuditSQL(dbTable, auditTable DbTableMeta) (string, error) {
+	keys := primaryKeyColumns(dbTable)
 
-	if primaryCnt == 0 {
+	if len(keys) == 0 {
 		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
 	}
 
-	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`UPDATE "%s" set`, dbTable.TableName()))
+	d := DialectForTable(dbTable)
+	if d.Name() != "postgres" {
+		return "", fmt.Errorf("table %s dialect %s does not support data modifying CTEs, cannot generate sql", dbTable.TableName(), d.Name())
+	}
 
-	setCol := 1
+	var cols []string
 	for _, col := range dbTable.Columns() {
-		if col.Name() != "deleted_at" && col.Name() != "DeletedAt" {
-			continue
+		if _, ok := findColumn(auditTable, col.Name()); !ok {
+			return "", fmt.Errorf("audit table %s does not have the column %s of table %s, cannot generate sql", auditTable.TableName(), col.Name(), dbTable.TableName())
 		}
+		cols = append(cols, col.Name())
+	}
+
+	where := newKeyWhere(d, SQLOptions{}, false, 1)
+	for _, col := range keys {
+		where.addKey(col)
+	}
+
+	columns := strings.Join(cols, ", ")
+	return fmt.Sprintf("WITH deleted AS (DELETE FROM %s WHERE %s RETURNING *) INSERT INTO %s (%s) SELECT %s FROM deleted",
+		d.QuoteIdentifier(dbTable.TableName()), where.String(), d.QuoteIdentifier(auditTable.TableName()), columns, columns), nil
+}
+
+// GenerateSoftDeleteSQL generate sql for a soft delete (update), setting the deleted at column followed by the deleted by
+// column when the table has one. The column names default to deleted_at and deleted_by, see SQLOptions.SoftDeleteColumn
+// and SQLOptions.DeletedByColumn.
+func GenerateSoftDeleteSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	keys := primaryKeyColumns(dbTable)
+	primaryCnt := len(keys)
+
+	if primaryCnt == 0 {
+		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
 
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("UPDATE %s set", d.QuoteIdentifier(dbTable.TableName())))
+
+	deletedAt, ok := findSoftDeleteColumn(dbTable, opt)
+	if !ok {
+		return "", fmt.Errorf("table %s does not have a deleted at column, cannot generate sql", dbTable.TableName())
+	}
+	setCols := []ColumnMeta{deletedAt}
+
+	deletedByName := opt.DeletedByColumn
+	if deletedByName == "" {
+		deletedByName = defaultDeletedByColumn
+	}
+	if deletedBy, ok := findColumn(dbTable, deletedByName); ok {
+		setCols = append(setCols, deletedBy)
+	}
+
+	setCol := 1
+	for _, col := range setCols {
 		if setCol != 1 {
 			buf.WriteString(",")
 		}
 
-		param := fmt.Sprintf("$%d", setCol)
+		param := opt.castParam(col, d.Placeholder(setCol))
 		if namedParams {
-			param = fmt.Sprintf("@upd_%s_%d", col.Name(), setCol)
+			param = d.NamedPlaceholder(fmt.Sprintf("upd_%s_%d", col.Name(), setCol))
 		}
 		buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
 		setCol++
 	}
 
-	if setCol == 1 {
-		return "", fmt.Errorf("table %s does not have a deleted at column, cannot generate sql", dbTable.TableName())
-	}
-
 	buf.WriteString(" WHERE")
-	for _, col := range dbTable.Columns() {
-		if col.IsPrimaryKey() {
-			param := fmt.Sprintf("$%d", setCol)
-			if namedParams {
-				param = fmt.Sprintf("@%s", col.Name())
-			}
-			buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
+	for i, col := range keys {
+		if i > 0 {
+			buf.WriteString(" AND")
+		}
 
-			setCol++
+		param := opt.castParam(col, d.Placeholder(setCol))
+		if namedParams {
+			param = d.NamedPlaceholder(col.Name())
 		}
+		buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
+		setCol++
 	}
 
-	return buf.String(), nil
+	return opt.annotated(dbTable, "soft_delete", buf.String()), nil
 }
 
-// GenerateUpdateSQL generate sql for a update
-func GenerateUpdateSQL(dbTable DbTableMeta, namedParams bool) (string, error) {
-	primaryCnt := PrimaryKeyCount(dbTable)
-	// nonPrimaryCnt := len(dbTable.Columns()) - primaryCnt
+// GenerateUpdateSQL generate sql for a update, ErrNothingToUpdate is returned if every column is a primary key or
+// excluded by the Include filter
+func GenerateUpdateSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	keys := primaryKeyColumns(dbTable)
+	primaryCnt := len(keys)
 
 	if primaryCnt == 0 {
 		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
 	}
 
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	err = validateJSONMergeColumns(d, dbTable, opt)
+	if err != nil {
+		return "", err
+	}
+
 	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`UPDATE "%s" SET`, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("UPDATE %s SET", d.QuoteIdentifier(dbTable.TableName())))
 
 	setCol := 1
 	for _, col := range dbTable.Columns() {
-		if !col.IsPrimaryKey() {
+		if !col.IsPrimaryKey() && opt.includeColumn(col) {
 			if setCol != 1 {
 				buf.WriteString(",")
 			}
 
-			param := fmt.Sprintf("$%d", setCol)
+			param := opt.castParam(col, d.Placeholder(setCol))
 			if namedParams {
-				param = fmt.Sprintf("@%s", col.Name())
+				param = d.NamedPlaceholder(col.Name())
+			}
+
+			if _, merge := FindInSlice(opt.JSONMergeColumns, col.Name()); merge {
+				buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), jsonMergeExpr(col, param)))
+			} else {
+				buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
 			}
-			buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
 			setCol++
 		}
 	}
 
+	if setCol == 1 {
+		return "", fmt.Errorf("table %s cannot generate update sql: %w", dbTable.TableName(), ErrNothingToUpdate)
+	}
+
 	buf.WriteString(" WHERE")
-	addedKey := 1
-	for _, col := range dbTable.Columns() {
+	for i, col := range keys {
+		if i > 0 {
+			buf.WriteString(" AND")
+		}
+
+		param := opt.castParam(col, d.Placeholder(setCol))
+		if namedParams {
+			param = d.NamedPlaceholder(fmt.Sprintf("where_%s", col.Name()))
+		}
+		buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
+		setCol++
+	}
+
+	return opt.annotated(dbTable, "update", buf.String()), nil
+}
+
+// GenerateUpdateColumnsSQL generate sql for a partial update setting only the named columns, in table order, followed by
+// the primary key predicates. Unless SQLOptions.Returning is set the updated record returns exactly the updated columns
+// and the primary keys, listed in the statement Columns for scanning. MySQL does not support returning the written rows
+// so nothing is returned by default. ErrNothingToUpdate is returned if no column is named.
+func GenerateUpdateColumnsSQL(dbTable DbTableMeta, columns []string, namedParams bool, opts ...SQLOptions) (*GeneratedStatement, error) {
+	keys := primaryKeyColumns(dbTable)
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make(map[string]bool)
+	for _, name := range columns {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return nil, fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), name)
+		}
 		if col.IsPrimaryKey() {
-			param := fmt.Sprintf("$%d", addedKey+setCol)
-			if namedParams {
-				param = fmt.Sprintf("@where_%s", col.Name())
-			}
-			buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
+			return nil, fmt.Errorf("table %s update column %s is a primary key, cannot generate sql", dbTable.TableName(), name)
+		}
+		updated[name] = true
+	}
 
-			setCol++
-			addedKey++
+	if len(updated) == 0 {
+		return nil, fmt.Errorf("table %s cannot generate update sql: %w", dbTable.TableName(), ErrNothingToUpdate)
+	}
 
-			if addedKey < primaryCnt {
-				buf.WriteString(" AND")
+	var sets []string
+	var params []StatementParam
+	pos := 1
+	for _, col := range dbTable.Columns() {
+		if !updated[col.Name()] {
+			continue
+		}
+
+		param := opt.castParam(col, d.Placeholder(pos))
+		if namedParams {
+			param = d.NamedPlaceholder(col.Name())
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", col.Name(), param))
+		params = append(params, newStatementParam(col.Name(), col))
+		pos++
+	}
+
+	if len(opt.Returning) == 0 && d.Name() != "mysql" {
+		for _, col := range dbTable.Columns() {
+			if updated[col.Name()] || col.IsPrimaryKey() {
+				opt.Returning = append(opt.Returning, col.Name())
 			}
 		}
 	}
 
-	return buf.String(), nil
+	returning, err := opt.returningColumns(d, dbTable, "INSERTED")
+	if err != nil {
+		return nil, err
+	}
+
+	var predicates []string
+	for _, col := range keys {
+		name := col.Name()
+		param := opt.castParam(col, d.Placeholder(pos))
+		if namedParams {
+			name = fmt.Sprintf("where_%s", col.Name())
+			param = d.NamedPlaceholder(name)
+		}
+		predicates = append(predicates, fmt.Sprintf("%s = %s", col.Name(), param))
+		params = append(params, newStatementParam(name, col))
+		pos++
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("UPDATE %s SET %s", d.QuoteIdentifier(dbTable.TableName()), strings.Join(sets, ", ")))
+	if len(returning) > 0 && d.Name() == "mssql" {
+		buf.WriteString(fmt.Sprintf(" OUTPUT %s", strings.Join(returning, ", ")))
+	}
+	buf.WriteString(fmt.Sprintf(" WHERE %s", strings.Join(predicates, " AND ")))
+	if len(returning) > 0 && d.Name() != "mssql" {
+		buf.WriteString(fmt.Sprintf(" RETURNING %s", strings.Join(returning, ", ")))
+	}
+
+	return &GeneratedStatement{
+		Name:    StatementName(dbTable, "update_columns", params),
+		SQL:     opt.annotated(dbTable, "update_columns", buf.String()),
+		Params:  params,
+		Columns: returnedColumns(dbTable, opt.Returning),
+	}, nil
 }
 
-// GenerateInsertSQL generate sql for a insert
-func GenerateInsertSQL(dbTable DbTableMeta, namedParams bool) (string, error) {
+// GenerateUpdateFromSQL generate sql for a correlated update of the target records from the source records joined on the
+// join columns, which must exist on both tables. setMap maps the target columns to the source columns they are set from,
+// the columns are set in target table order. Only Postgres and Sqlite support UPDATE ... FROM.
+func GenerateUpdateFromSQL(target, source DbTableMeta, joinCols []string, setMap map[string]string) (string, error) {
+	d := DialectForTable(target)
+	if d.Name() != "postgres" && d.Name() != "sqlite3" {
+		return "", fmt.Errorf("table %s dialect %s does not support UPDATE ... FROM, cannot generate sql", target.TableName(), d.Name())
+	}
+
+	if len(joinCols) == 0 {
+		return "", fmt.Errorf("table %s update from %s requires join columns, cannot generate sql", target.TableName(), source.TableName())
+	}
+
+	if len(setMap) == 0 {
+		return "", fmt.Errorf("table %s cannot generate update sql: %w", target.TableName(), ErrNothingToUpdate)
+	}
+
+	targetRef := d.QuoteIdentifier(target.TableName())
+	sourceRef := d.QuoteIdentifier(source.TableName())
+
+	var join []string
+	for _, name := range joinCols {
+		if _, ok := findColumn(target, name); !ok {
+			return "", fmt.Errorf("table %s does not have a join column %s, cannot generate sql", target.TableName(), name)
+		}
+		if _, ok := findColumn(source, name); !ok {
+			return "", fmt.Errorf("table %s does not have a join column %s, cannot generate sql", source.TableName(), name)
+		}
+		join = append(join, fmt.Sprintf("%s.%s = %s.%s", targetRef, name, sourceRef, name))
+	}
+
+	for name, sourceCol := range setMap {
+		if _, ok := findColumn(target, name); !ok {
+			return "", fmt.Errorf("table %s does not have a set column %s, cannot generate sql", target.TableName(), name)
+		}
+		if _, ok := findColumn(source, sourceCol); !ok {
+			return "", fmt.Errorf("table %s does not have a source column %s, cannot generate sql", source.TableName(), sourceCol)
+		}
+	}
+
+	var sets []string
+	for _, col := range target.Columns() {
+		if sourceCol, ok := setMap[col.Name()]; ok {
+			sets = append(sets, fmt.Sprintf("%s = %s.%s", col.Name(), sourceRef, sourceCol))
+		}
+	}
+
+	return fmt.Sprintf("UPDATE %s SET %s FROM %s WHERE %s", targetRef, strings.Join(sets, ", "), sourceRef, strings.Join(join, " AND ")), nil
+}
+
+// GenerateUpdateChangedSQL generate sql for an update setting only the columns whose value differs between the before
+// and after snapshots of a record, keyed by column name. The changed columns are set in table order followed by the
+// primary keys, taken from the before snapshot, as listed in the statement Params. ErrNothingToUpdate is returned if no
+// column changed.
+func GenerateUpdateChangedSQL(dbTable DbTableMeta, before, after map[string]interface{}, namedParams bool) (*GeneratedStatement, error) {
+	keys := primaryKeyColumns(dbTable)
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	for name := range after {
+		if _, ok := findColumn(dbTable, name); !ok {
+			return nil, fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+
+	d := DialectForTable(dbTable)
+
+	var sets []string
+	var params []StatementParam
+	pos := 1
+	for _, col := range dbTable.Columns() {
+		value, ok := after[col.Name()]
+		if !ok || col.IsPrimaryKey() {
+			continue
+		}
+
+		if previous, ok := before[col.Name()]; ok && reflect.DeepEqual(previous, value) {
+			continue
+		}
+
+		param := d.Placeholder(pos)
+		if namedParams {
+			param = d.NamedPlaceholder(col.Name())
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", col.Name(), param))
+		params = append(params, newStatementParam(col.Name(), col))
+		pos++
+	}
+
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("table %s cannot generate update sql: %w", dbTable.TableName(), ErrNothingToUpdate)
+	}
+
+	var predicates []string
+	for _, col := range keys {
+		if _, ok := before[col.Name()]; !ok {
+			return nil, fmt.Errorf("table %s before snapshot is missing the primary key %s, cannot generate sql", dbTable.TableName(), col.Name())
+		}
+
+		name := col.Name()
+		param := d.Placeholder(pos)
+		if namedParams {
+			name = fmt.Sprintf("where_%s", col.Name())
+			param = d.NamedPlaceholder(name)
+		}
+		predicates = append(predicates, fmt.Sprintf("%s = %s", col.Name(), param))
+		params = append(params, newStatementParam(name, col))
+		pos++
+	}
+
+	return &GeneratedStatement{
+		Name:   StatementName(dbTable, "update_changed", params),
+		SQL:    fmt.Sprintf("UPDATE %s SET %s WHERE %s", d.QuoteIdentifier(dbTable.TableName()), strings.Join(sets, ", "), strings.Join(predicates, " AND ")),
+		Params: params,
+	}, nil
+}
+
+// validateJSONMergeColumns check the json merge columns are json columns set by the update
+func validateJSONMergeColumns(d Dialect, dbTable DbTableMeta, opt SQLOptions) error {
+	if len(opt.JSONMergeColumns) == 0 {
+		return nil
+	}
+
+	if d.Name() != "postgres" {
+		return fmt.Errorf("table %s dialect %s does not support json merge columns, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	for _, name := range opt.JSONMergeColumns {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return fmt.Errorf("table %s does not have a json merge column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+		if !col.IsJSON() {
+			return fmt.Errorf("table %s json merge column %s type %s is not json or jsonb, cannot generate sql", dbTable.TableName(), name, col.DatabaseTypeName())
+		}
+		if col.IsPrimaryKey() || !opt.includeColumn(col) {
+			return fmt.Errorf("table %s json merge column %s is not updated, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+	return nil
+}
+
+// jsonMergeExpr expression merging the column with the parameter, json columns are merged as jsonb
+func jsonMergeExpr(col ColumnMeta, param string) string {
+	if strings.ToLower(col.DatabaseTypeName()) == "jsonb" {
+		return fmt.Sprintf("%s || %s::jsonb", col.Name(), param)
+	}
+	return fmt.Sprintf("(%s::jsonb || %s::jsonb)::json", col.Name(), param)
+}
+
+// GenerateInsertSQL generate sql for a insert. Auto increment columns are always left to the database and skipped from
+// the insert, whether or not they are primary keys, if no column is left to insert a DEFAULT VALUES insert is generated,
+// INSERT INTO t () VALUES () for MySQL, or ErrNoInsertableColumns is returned when SQLOptions.ErrorOnEmptyInsert is set.
+// The columns are inserted in SQLOptions.ColumnOrder when set. Postgres array column parameters are cast to the array
+// type.
+func GenerateInsertSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	stmt, err := GenerateInsertStatement(dbTable, namedParams, opts...)
+	if err != nil {
+		return "", err
+	}
+	return stmt.SQL, nil
+}
+
+// GenerateInsertStatement generate the statement for a insert along with its name and parameters, see GenerateInsertSQL.
+// The inserted record returns SQLOptions.Returning, or when ReturnGenerated is set every column the insert does not
+// supply, e.g. auto increment keys and columns left to their database default. The returned columns are listed in the
+// statement Columns.
+func GenerateInsertStatement(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (*GeneratedStatement, error) {
 	primaryCnt := PrimaryKeyCount(dbTable)
 
 	if primaryCnt == 0 {
-		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validateMutation()
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+
+	insertable, err := insertColumns(dbTable, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateSequences(d, dbTable, insertable, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opt.Returning) == 0 && opt.ReturnGenerated {
+		supplied := make(map[string]bool)
+		for _, col := range insertable {
+			if _, ok := opt.Sequences[col.Name()]; !ok {
+				supplied[col.Name()] = true
+			}
+		}
+		for _, col := range dbTable.Columns() {
+			if !supplied[col.Name()] {
+				opt.Returning = append(opt.Returning, col.Name())
+			}
+		}
+	}
+
+	returning, err := opt.returningColumns(d, dbTable, "INSERTED")
+	if err != nil {
+		return nil, err
+	}
+
+	output := ""
+	if len(returning) > 0 && d.Name() == "mssql" {
+		output = " OUTPUT " + strings.Join(returning, ", ")
+	}
+
+	if len(insertable) == 0 && opt.ErrorOnEmptyInsert {
+		return nil, fmt.Errorf("table %s cannot generate insert sql: %w", dbTable.TableName(), ErrNoInsertableColumns)
 	}
 
 	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`INSERT INTO "%s" (`, dbTable.TableName()))
+	var params []StatementParam
+	if len(insertable) == 0 {
+		sql := generateDefaultValuesInsertSQL(d, dbTable)
+		if output != "" {
+			sql = strings.TrimSuffix(sql, " DEFAULT VALUES") + output + " DEFAULT VALUES"
+		}
+		buf.WriteString(sql)
+	} else {
+		// the column and value lists are built from the same columns so they always align
+		names := make([]string, len(insertable))
+		values := make([]string, len(insertable))
+		pos := 1
+		for i, col := range insertable {
+			names[i] = col.Name()
+			if seq, ok := opt.Sequences[col.Name()]; ok {
+				values[i] = fmt.Sprintf("nextval('%s')", seq)
+				continue
+			}
+
+			param := d.Placeholder(pos)
+			pos++
+			if namedParams {
+				param = d.NamedPlaceholder(col.Name())
+			} else if arrayType, ok := arrayParamType(col); ok && d.Name() == "postgres" {
+				param += "::" + arrayType
+			}
+
+			values[i] = param
+			params = append(params, newStatementParam(col.Name(), col))
+		}
+
+		buf.WriteString(fmt.Sprintf("INSERT INTO %s (%s)%s VALUES (%s", d.QuoteIdentifier(dbTable.TableName()),
+			strings.Join(names, ", "), output, strings.Join(values, ", ")))
+		buf.WriteString(")")
+	}
 
-	pastFirst := false
+	if len(returning) > 0 && d.Name() != "mssql" {
+		buf.WriteString(fmt.Sprintf(" RETURNING %s", strings.Join(returning, ", ")))
+	}
+
+	return &GeneratedStatement{
+		Name:    StatementName(dbTable, "insert", params),
+		SQL:     opt.annotated(dbTable, "insert", buf.String()),
+		Params:  params,
+		Columns: returnedColumns(dbTable, opt.Returning),
+	}, nil
+}
+
+// validateSequences check the SQLOptions.Sequences map inserted primary keys to safe sequence names, only Postgres
+// supports nextval
+func validateSequences(d Dialect, dbTable DbTableMeta, insertable []ColumnMeta, opt SQLOptions) error {
+	if len(opt.Sequences) > 0 && d.Name() != "postgres" {
+		return fmt.Errorf("table %s dialect %s does not support nextval sequences, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	for name, seq := range opt.Sequences {
+		found := false
+		for _, col := range insertable {
+			if col.Name() == name {
+				found = col.IsPrimaryKey()
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("table %s sequence column %s is not an inserted primary key, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		err := validateIdentifier("sequence", seq)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertColumns columns set by an insert, auto increment and excluded columns are left out. The columns are in table
+// order unless SQLOptions.ColumnOrder is set, which must be a permutation of the insertable columns. ErrDuplicateColumn
+// is returned if the table lists a column more than once.
+func insertColumns(dbTable DbTableMeta, opt SQLOptions) ([]ColumnMeta, error) {
+	err := validateUniqueColumns(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var cols []ColumnMeta
 	for _, col := range dbTable.Columns() {
-		if pastFirst {
-			buf.WriteString(", ")
+		if !col.IsAutoIncrement() && opt.includeColumn(col) {
+			cols = append(cols, col)
 		}
+	}
 
-		buf.WriteString(fmt.Sprintf(" %s", col.Name()))
-		pastFirst = true
+	if len(opt.ColumnOrder) == 0 {
+		return cols, nil
 	}
-	buf.WriteString(") values ( ")
 
-	pastFirst = false
-	pos := 1
-	for i, col := range dbTable.Columns() {
-		if pastFirst {
-			buf.WriteString(", ")
+	ordered := make([]ColumnMeta, 0, len(cols))
+	seen := make(map[string]bool)
+	for _, name := range opt.ColumnOrder {
+		if seen[name] {
+			return nil, fmt.Errorf("table %s column order lists column %s more than once, cannot generate sql", dbTable.TableName(), name)
 		}
+		seen[name] = true
 
-		param := fmt.Sprintf("$%d", i+1)
-		if namedParams {
-			param = fmt.Sprintf("@%s", col.Name())
+		found := false
+		for _, col := range cols {
+			if col.Name() == name {
+				ordered = append(ordered, col)
+				found = true
+				break
+			}
 		}
+		if !found {
+			return nil, fmt.Errorf("table %s column order column %s is not an insertable column, cannot generate sql", dbTable.TableName(), name)
+		}
+	}
+
+	for _, col := range cols {
+		if !seen[col.Name()] {
+			return nil, fmt.Errorf("table %s column order is missing the insertable column %s, cannot generate sql", dbTable.TableName(), col.Name())
+		}
+	}
+	return ordered, nil
+}
+
+// generateDefaultValuesInsertSQL generate sql for a insert where every column is populated by the database
+func generateDefaultValuesInsertSQL(d Dialect, dbTable DbTableMeta) string {
+	switch d.Name() {
+	case "mysql":
+		return fmt.Sprintf("INSERT INTO %s () VALUES ()", d.QuoteIdentifier(dbTable.TableName()))
+	default:
+		return fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", d.QuoteIdentifier(dbTable.TableName()))
+	}
+}
+
+// GenerateInsertReturningIDSQL generate sql for a insert returning the generated primary key, the table must have
+// exactly one auto increment column. SQL Server uses OUTPUT INSERTED, MySQL does not support returning the key and the
+// LastInsertId of the result should be used instead. The id is returned first, followed by the SQLOptions.Returning
+// columns when set.
+func GenerateInsertReturningIDSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	var autoIncrement []ColumnMeta
+	for _, col := range dbTable.Columns() {
 		if col.IsAutoIncrement() {
-			param = "default"
+			autoIncrement = append(autoIncrement, col)
+		}
+	}
+
+	if len(autoIncrement) != 1 {
+		return "", fmt.Errorf("table %s has %d auto increment columns, expected exactly one, cannot generate sql", dbTable.TableName(), len(autoIncrement))
+	}
+	idCol := autoIncrement[0]
+
+	d := DialectForTable(dbTable)
+	if d.Name() == "mysql" {
+		return "", fmt.Errorf("table %s dialect %s does not support returning the inserted id, use the result LastInsertId", dbTable.TableName(), d.Name())
+	}
+
+	// the id leads the SQLOptions.Returning columns, so the statement has a single returning clause
+	opt := assureSQLOptions(opts...)
+	_, returned := FindInSlice(opt.Returning, idCol.Name())
+	_, all := FindInSlice(opt.Returning, "*")
+	if !returned && !all && !(opt.ReturnGenerated && len(opt.Returning) == 0) {
+		opt.Returning = append([]string{idCol.Name()}, opt.Returning...)
+	}
+
+	stmt, err := GenerateInsertStatement(dbTable, namedParams, opt)
+	if err != nil {
+		return "", err
+	}
+	return stmt.SQL, nil
+}
+
+// GenerateSelectOneSQL generate sql for selecting one record, soft deleted records are excluded unless
+// SQLOptions.IncludeDeleted is set and the SQLOptions.Lock row lock is taken
+func GenerateSelectOneSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	stmt, err := GenerateSelectOneStatement(dbTable, namedParams, opts...)
+	if err != nil {
+		return "", err
+	}
+	return stmt.SQL, nil
+}
+
+// GenerateSelectOneDualSQL generate both the positional and the named form of the sql for selecting one record from the
+// same options, e.g. to execute the positional form and log the named one
+func GenerateSelectOneDualSQL(dbTable DbTableMeta, opts ...SQLOptions) (positional, named string, err error) {
+	positional, err = GenerateSelectOneSQL(dbTable, false, opts...)
+	if err != nil {
+		return "", "", err
+	}
+
+	named, err = GenerateSelectOneSQL(dbTable, true, opts...)
+	if err != nil {
+		return "", "", err
+	}
+	return positional, named, nil
+}
+
+// systemTimeTableRef reference to the table as of the point in time bound to the parameter at pos, supported by system
+// versioned tables on SQL Server and MariaDB. The partition, schema and index hint of the table reference are kept.
+func systemTimeTableRef(d Dialect, dbTable DbTableMeta, opt SQLOptions, namedParams bool, pos int) (string, error) {
+	if d.Name() != "mssql" && d.Name() != "mysql" {
+		return "", fmt.Errorf("table %s dialect %s does not support FOR SYSTEM_TIME AS OF, cannot generate sql", dbTable.TableName(), d.Name())
+	}
+
+	param := d.Placeholder(pos)
+	if namedParams {
+		param = d.NamedPlaceholder("as_of")
+	}
+
+	ref := fmt.Sprintf("%s FOR SYSTEM_TIME AS OF %s", opt.quotedTable(d, dbTable), param)
+	if opt.TableAlias != "" {
+		ref = ref + " AS " + opt.TableAlias
+	}
+	return ref + opt.indexHint(d, dbTable), nil
+}
+
+// GenerateSelectOneStatement generate the statement for selecting one record along with its name and parameters. When
+// SQLOptions.TenantColumn is set the record is also matched on the tenant, bound after the primary keys at TenantParam,
+// e.g. WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL. The sql is prefixed with its StatementID comment when
+// SQLOptions.StatementIDComment is set.
+func GenerateSelectOneStatement(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (*GeneratedStatement, error) {
+	keys := primaryKeyColumns(dbTable)
+	primaryCnt := len(keys)
+
+	if primaryCnt == 0 {
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+	if opt.TimeZone {
+		opt.timeZoneParam = d.Placeholder(1)
+		if namedParams {
+			opt.timeZoneParam = d.NamedPlaceholder("tz")
 		}
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := ProjectedColumns(dbTable, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// the time zone is bound once, ? placeholders bind it for every converted column
+	var params []StatementParam
+	if opt.TimeZone {
+		for _, name := range columns {
+			if col, ok := findColumn(dbTable, name); ok && isTimeZoneColumn(d, col) {
+				params = append(params, StatementParam{Name: "tz"})
+				if namedParams || d.Placeholder(1) != d.Placeholder(2) {
+					break
+				}
+			}
+		}
+	}
+
+	var jsonColumns []string
+	for _, name := range columns {
+		if col, ok := findColumn(dbTable, name); ok && col.IsJSON() {
+			jsonColumns = append(jsonColumns, name)
+		}
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	err = opt.validatePredicates(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	err = opt.validateDialect(d)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := lockClause(d, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	tableRef := opt.tableRef(d, dbTable)
+	startAt := len(params) + 1
+	if opt.AsOf {
+		tableRef, err = systemTimeTableRef(d, dbTable, opt, namedParams, startAt)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, StatementParam{Name: "as_of"})
+		startAt++
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE", projection, tableRef))
+
+	where := newKeyWhere(d, opt, namedParams, startAt)
+	for _, col := range keys {
+		where.addKey(col)
+	}
+
+	tenantParam := 0
+	if tenantCol != nil {
+		where.addTenant(tenantCol)
+		tenantParam = len(params) + len(where.params)
+	}
+
+	err = where.addBools(dbTable, opt.BoolPredicates)
+	if err != nil {
+		return nil, err
+	}
+	params = append(params, where.params...)
 
-		buf.WriteString(fmt.Sprintf("%s", param))
+	buf.WriteString(" " + where.String())
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	buf.WriteString(lock)
+
+	sql := opt.routed(opt.annotated(dbTable, "select_one", opt.hinted(d, dbTable, buf.String())))
+	return &GeneratedStatement{
+		Name:        StatementName(dbTable, "select_one", params),
+		SQL:         sql,
+		Params:      params,
+		Columns:     columns,
+		JSONColumns: jsonColumns,
+		TenantParam: tenantParam,
+	}, nil
+}
+
+// GenerateSelectByColumnsSQL generate sql for selecting the records matching a parameter per column, e.g. a lookup by
+// email. Soft deleted records are excluded unless SQLOptions.IncludeDeleted is set, SQLOptions.PredicateExpressions wrap
+// the predicates so they match a functional index. The tenant is bound after the columns unless it is one of them.
+func GenerateSelectByColumnsSQL(dbTable DbTableMeta, columns []string, namedParams bool, opts ...SQLOptions) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table %s no columns to select by, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	err = opt.validatePredicates(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	where := newKeyWhere(d, opt, namedParams, 1)
+	for _, name := range columns {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return "", fmt.Errorf("table %s does not have a column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+		where.addKey(col)
+	}
+
+	if tenantCol != nil {
+		if _, ok := FindInSlice(columns, tenantCol.Name()); !ok {
+			where.addTenant(tenantCol)
+		}
+	}
+
+	err = where.addBools(dbTable, opt.BoolPredicates)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE %s", projection, opt.tableRef(d, dbTable), where.String()))
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	return opt.routed(opt.annotated(dbTable, "select_by_columns", opt.hinted(d, dbTable, buf.String()))), nil
+}
+
+// GenerateSelectOneByUniqueSQL generate sql for selecting the record matching the unique index of the table, e.g.
+// UNIQUE (tenant_id, email). The index columns are matched in index order, followed by the SQLOptions.TenantColumn when
+// the index does not already scope the records by tenant.
+func GenerateSelectOneByUniqueSQL(dbTable DbTableMeta, indexName string, namedParams bool, opts ...SQLOptions) (string, error) {
+	var idx *Index
+	for i, candidate := range dbTable.Indexes() {
+		if candidate.Name == indexName {
+			idx = &dbTable.Indexes()[i]
+			break
+		}
+	}
+	if idx == nil {
+		return "", fmt.Errorf("table %s does not have an index %s, cannot generate sql", dbTable.TableName(), indexName)
+	}
+
+	if !idx.Unique || len(idx.Columns) == 0 {
+		return "", fmt.Errorf("table %s index %s is not a unique index, cannot generate sql", dbTable.TableName(), indexName)
+	}
+
+	opt := assureSQLOptions(opts...)
+	columns := append([]string{}, idx.Columns...)
+	if opt.TenantColumn != "" {
+		if _, ok := FindInSlice(columns, opt.TenantColumn); !ok {
+			columns = append(columns, opt.TenantColumn)
+		}
+	}
+	return GenerateSelectByColumnsSQL(dbTable, columns, namedParams, opt)
+}
+
+// GenerateSelectChangedSQL generate sql selecting 1 when the record with the primary keys has a non primary key column
+// differing from its parameter, compared null safely, e.g. to check whether an update is needed before writing. The
+// primary keys are bound first, followed by the tenant of tenant scoped records and the compared columns in table order
+// as listed in the statement Params.
+func GenerateSelectChangedSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (*GeneratedStatement, error) {
+	keys := primaryKeyColumns(dbTable)
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return nil, err
+	}
+
+	where := newKeyWhere(d, opt, namedParams, 1)
+	for _, col := range keys {
+		where.addKey(col)
+	}
+	if tenantCol != nil {
+		where.addTenant(tenantCol)
+	}
+
+	params := where.params
+	pos := len(params) + 1
+	var distinct []string
+	for _, col := range dbTable.Columns() {
+		if col.IsPrimaryKey() || col == tenantCol || !opt.includeColumn(col) {
+			continue
+		}
+
+		param := opt.castParam(col, d.Placeholder(pos))
+		if namedParams {
+			param = d.NamedPlaceholder(col.Name())
+		}
+		distinct = append(distinct, distinctFrom(d, opt.columnRef(col.Name()), param))
+		params = append(params, newStatementParam(col.Name(), col))
 		pos++
-		pastFirst = true
 	}
 
-	buf.WriteString(" )")
-	return buf.String(), nil
+	if len(distinct) == 0 {
+		return nil, fmt.Errorf("table %s cannot generate changed sql: %w", dbTable.TableName(), ErrNothingToUpdate)
+	}
+
+	sql := fmt.Sprintf("SELECT 1 FROM %s WHERE %s AND (%s)", opt.tableRef(d, dbTable), where.String(), strings.Join(distinct, " OR "))
+	return &GeneratedStatement{
+		Name:   StatementName(dbTable, "select_changed", params),
+		SQL:    opt.routed(opt.annotated(dbTable, "select_changed", opt.hinted(d, dbTable, sql))),
+		Params: params,
+	}, nil
 }
 
-// GenerateSelectOneSQL generate sql for selecting one record
-func GenerateSelectOneSQL(dbTable DbTableMeta, namedParams bool) (string, error) {
-	primaryCnt := PrimaryKeyCount(dbTable)
+// GenerateSelectMultiSQL generate sql for selecting multiple records, soft deleted records are excluded unless
+// SQLOptions.IncludeDeleted is set. Postgres binds an array per primary key with = ANY(), other dialects do not support
+// arrays and select by an IN list of SQLOptions.IDCount placeholders, which requires a single primary key. The tenant is
+// bound after the ids.
+func GenerateSelectMultiSQL(dbTable DbTableMeta, namedParams bool, opts ...SQLOptions) (string, error) {
+	keys := primaryKeyColumns(dbTable)
+	primaryCnt := len(keys)
 
 	if primaryCnt == 0 {
 		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
 	}
 
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
 	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`SELECT * FROM "%s" WHERE`, dbTable.TableName()))
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE", projection, opt.tableRef(d, dbTable)))
 
-	pastFirst := false
-	pos := 1
-	for i, col := range dbTable.Columns() {
-		if col.IsPrimaryKey() {
-			if pastFirst {
-				buf.WriteString(" AND ")
+	if d.Name() != "postgres" {
+		if primaryCnt > 1 {
+			return "", fmt.Errorf("table %s has a composite primary key, dialect %s cannot select multiple records", dbTable.TableName(), d.Name())
+		}
+
+		if opt.IDCount <= 0 || opt.IDCount > maxBindParams {
+			return "", fmt.Errorf("invalid id count %d, dialect %s requires an id count between 1 and %d", opt.IDCount, d.Name(), maxBindParams)
+		}
+
+		primaryKey := keys[0].Name()
+		buf.WriteString(fmt.Sprintf(" %s IN (", opt.columnRef(primaryKey)))
+		for i := 1; i <= opt.IDCount; i++ {
+			if i > 1 {
+				buf.WriteString(", ")
 			}
 
-			param := fmt.Sprintf("$%d", i+1)
+			param := d.Placeholder(i)
 			if namedParams {
-				param = fmt.Sprintf("@where_%s_%d", col.Name(), i+1)
+				param = d.NamedPlaceholder(fmt.Sprintf("where_%s_%d", primaryKey, i))
 			}
-			buf.WriteString(fmt.Sprintf(" %s = %s", col.Name(), param))
-			pos++
-			pastFirst = true
+			buf.WriteString(param)
+		}
+		buf.WriteString(")")
+		if tenantCol != nil {
+			where := newKeyWhere(d, opt, namedParams, opt.IDCount+1)
+			where.addTenant(tenantCol)
+			buf.WriteString(" AND " + where.String())
 		}
+		buf.WriteString(softDeleteFilter(dbTable, opt))
+		return opt.routed(opt.annotated(dbTable, "select_multi", opt.hinted(d, dbTable, buf.String()))), nil
 	}
-	return buf.String(), nil
+
+	where := newKeyWhere(d, opt, namedParams, 1)
+	for _, col := range keys {
+		where.addKeyArray(col)
+	}
+	if tenantCol != nil {
+		where.addTenant(tenantCol)
+	}
+
+	buf.WriteString(" " + where.String())
+	buf.WriteString(softDeleteFilter(dbTable, opt))
+	return opt.routed(opt.annotated(dbTable, "select_multi", opt.hinted(d, dbTable, buf.String()))), nil
 }
 
-// GenerateSelectMultiSQL generate sql for selecting multiple records
-func GenerateSelectMultiSQL(dbTable DbTableMeta, namedParams bool) (string, error) {
+// maxBindParams max number of bind parameters supported in a single statement by common drivers
+const maxBindParams = 65535
+
+// GenerateSelectMultiChunkedSQL generate sql for selecting multiple records by a large list of ids. The id list is split
+// into chunks of chunkSize ids, one statement is returned per chunk and statement i is bound to
+// ids[i*chunkSize : min((i+1)*chunkSize, idCount)] followed by the tenant when tenant scoped. Soft deleted records are
+// excluded unless SQLOptions.IncludeDeleted is set. Only tables with a single primary key are supported.
+func GenerateSelectMultiChunkedSQL(dbTable DbTableMeta, idCount, chunkSize int, opts ...SQLOptions) ([]string, error) {
 	primaryCnt := PrimaryKeyCount(dbTable)
 
 	if primaryCnt == 0 {
-		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
+		return nil, fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
 	}
 
-	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`SELECT * FROM "%s" WHERE`, dbTable.TableName()))
+	if primaryCnt > 1 {
+		return nil, fmt.Errorf("table %s has a composite primary key, cannot generate chunked sql", dbTable.TableName())
+	}
 
-	pastFirst := false
-	pos := 1
-	for i, col := range dbTable.Columns() {
-		if col.IsPrimaryKey() {
-			if pastFirst {
-				buf.WriteString(" AND ")
-			}
+	if idCount <= 0 {
+		return nil, fmt.Errorf("invalid id count %d, must be greater than 0", idCount)
+	}
 
-			param := fmt.Sprintf("$%d", i+1)
-			if namedParams {
-				param = fmt.Sprintf("@where_%s_%d", col.Name(), i+1)
+	if chunkSize <= 0 || chunkSize > maxBindParams {
+		return nil, fmt.Errorf("invalid chunk size %d, must be between 1 and %d", chunkSize, maxBindParams)
+	}
+
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return nil, err
+	}
+
+	primaryKey := PrimaryKeyNames(dbTable)[0]
+
+	var statements []string
+	for start := 0; start < idCount; start += chunkSize {
+		size := chunkSize
+		if start+size > idCount {
+			size = idCount - start
+		}
+
+		buf := bytes.Buffer{}
+		buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (", projection, opt.tableRef(d, dbTable), opt.columnRef(primaryKey)))
+		for i := 0; i < size; i++ {
+			if i > 0 {
+				buf.WriteString(", ")
 			}
-			buf.WriteString(fmt.Sprintf(" %s = ANY(%s::%s[])", col.Name(), param, col.ColumnType()))
-			pos++
-			pastFirst = true
+			buf.WriteString(d.Placeholder(i + 1))
 		}
+		buf.WriteString(")")
+		if tenantCol != nil {
+			where := newKeyWhere(d, opt, false, size+1)
+			where.addTenant(tenantCol)
+			buf.WriteString(" AND " + where.String())
+		}
+		buf.WriteString(softDeleteFilter(dbTable, opt))
+		statements = append(statements, opt.routed(opt.annotated(dbTable, "select_multi", opt.hinted(d, dbTable, buf.String()))))
 	}
-	return buf.String(), nil
+	return statements, nil
 }
 
-// GenerateSelectAllSQL generate sql for selecting multiple records
-func GenerateSelectAllSQL(dbTable DbTableMeta) (string, error) {
+// DefaultMaxRows safety cap on the number of records selected by GenerateSelectAllSQL, guarding against accidental
+// unbounded scans. When greater than 0 the select is limited to DefaultMaxRows records unless SQLOptions.Unbounded is
+// set. Defaults to 0, no limit.
+var DefaultMaxRows = 0
+
+// GenerateSelectAllSQL generate sql for selecting multiple records, ordered when SQLOptions.OrderBy is set and capped at
+// DefaultMaxRows records. Soft deleted records are excluded unless SQLOptions.IncludeDeleted is set, tenant scoped
+// records are matched on the tenant bound to the first parameter.
+func GenerateSelectAllSQL(dbTable DbTableMeta, opts ...SQLOptions) (string, error) {
 	primaryCnt := PrimaryKeyCount(dbTable)
 
 	if primaryCnt == 0 {
 		return "", fmt.Errorf("table %s does not have a primary key, cannot generate sql", dbTable.TableName())
 	}
 
+	opt := assureSQLOptions(opts...)
+	err := opt.validate()
+	if err != nil {
+		return "", err
+	}
+
+	projection, err := opt.projection(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	tenantCol, err := opt.tenantColumn(dbTable)
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectForTable(dbTable)
+	err = opt.validateDialect(d)
+	if err != nil {
+		return "", err
+	}
+
+	maxRows := 0
+	if !opt.Unbounded && DefaultMaxRows > 0 {
+		maxRows = DefaultMaxRows
+	}
+
 	buf := bytes.Buffer{}
-	buf.WriteString(fmt.Sprintf(`SELECT * FROM "%s"`, dbTable.TableName()))
-	return buf.String(), nil
+	if maxRows > 0 && d.Name() == "mssql" {
+		projection = fmt.Sprintf("TOP (%d) %s", maxRows, projection)
+	}
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s", projection, opt.tableRef(d, dbTable)))
+	buf.WriteString(scopeFilter(d, dbTable, opt, tenantCol, false, 1))
+	if len(opt.OrderBy) > 0 {
+		orderBy, err := opt.orderBy(d, dbTable)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(fmt.Sprintf(" ORDER BY %s", strings.Join(orderBy, ", ")))
+	}
+
+	if maxRows > 0 && d.Name() != "mssql" {
+		buf.WriteString(fmt.Sprintf(" LIMIT %d", maxRows))
+	}
+	return opt.routed(opt.annotated(dbTable, "select_all", opt.hinted(d, dbTable, buf.String()))), nil
 }
@@ -0,0 +1,1794 @@
+package dbmeta
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testColumn(name, columnType string, index int) *columnMeta {
+	return &columnMeta{
+		index:            index,
+		name:             name,
+		databaseTypeName: columnType,
+		columnType:       columnType,
+		colDDL:           columnType,
+		columnLen:        -1,
+	}
+}
+
+func testUsersTable() *dbTableMeta {
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	id.isAutoIncrement = true
+
+	name := testColumn("name", "VARCHAR", 1)
+	name.columnLen = 64
+
+	email := testColumn("email", "VARCHAR", 2)
+	email.nullable = true
+
+	return &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "users",
+		columns:   []*columnMeta{id, name, email},
+	}
+}
+
+func Test_GenerateSelectMultiChunkedSQL(t *testing.T) {
+	statements, err := GenerateSelectMultiChunkedSQL(testUsersTable(), 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`SELECT * FROM "users" WHERE id IN ($1, $2)`,
+		`SELECT * FROM "users" WHERE id IN ($1, $2)`,
+		`SELECT * FROM "users" WHERE id IN ($1)`,
+	}
+	if !reflect.DeepEqual(expected, statements) {
+		t.Errorf("expect: %+v, but got %+v", expected, statements)
+	}
+
+	_, err = GenerateSelectMultiChunkedSQL(testUsersTable(), 0, 2)
+	if err == nil {
+		t.Errorf("expect error for id count 0")
+	}
+
+	_, err = GenerateSelectMultiChunkedSQL(testUsersTable(), 10, maxBindParams+1)
+	if err == nil {
+		t.Errorf("expect error for chunk size over %d", maxBindParams)
+	}
+}
+
+func Test_GenerateSelectOneSQL_TableAlias(t *testing.T) {
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{TableAlias: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT u.* FROM "users" u WHERE u.id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectAllSQL(testUsersTable(), SQLOptions{TableAlias: "u; DROP TABLE users"})
+	if err == nil {
+		t.Errorf("expect error for unsafe table alias")
+	}
+}
+
+func Test_GenerateInsertSQL_DefaultValues(t *testing.T) {
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	id.isAutoIncrement = true
+
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "tickets",
+		columns:   []*columnMeta{id},
+	}
+
+	sql, err := GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "tickets" DEFAULT VALUES`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mysql"
+	sql, err = GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "INSERT INTO `tickets` () VALUES ()"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "sqlite3"
+	sql, err = GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "tickets" DEFAULT VALUES`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mssql"
+	sql, err = GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "INSERT INTO [tickets] DEFAULT VALUES"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	for _, sqlType := range []string{"postgres", "mysql", "sqlite3", "mssql"} {
+		table.sqlType = sqlType
+		_, err = GenerateInsertSQL(table, false, SQLOptions{ErrorOnEmptyInsert: true})
+		if !errors.Is(err, ErrNoInsertableColumns) {
+			t.Errorf("%s expect: %v, but got %v", sqlType, ErrNoInsertableColumns, err)
+		}
+	}
+}
+
+func Test_GenerateInsertSQL_Golden(t *testing.T) {
+	code := testColumn("code", "VARCHAR", 0)
+	code.isPrimaryKey = true
+
+	table := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "countries",
+		columns:   []*columnMeta{code, testColumn("name", "VARCHAR", 1)},
+	}
+
+	sql, err := GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "countries" (code, name) VALUES ($1, $2)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateInsertSQL(table, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "countries" (code, name) VALUES (@code, @name)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_SQLOptions_Include(t *testing.T) {
+	opt := SQLOptions{
+		Include: func(col ColumnMeta) bool {
+			return col.Name() != "email"
+		},
+	}
+
+	tests := []struct {
+		name     string
+		generate func() (string, error)
+		expected string
+	}{
+		{"insert", func() (string, error) { return GenerateInsertSQL(testUsersTable(), false, opt) }, `INSERT INTO "users" (name) VALUES ($1)`},
+		{"update", func() (string, error) { return GenerateUpdateSQL(testUsersTable(), false, opt) }, `UPDATE "users" SET name = $1 WHERE id = $2`},
+		{"select", func() (string, error) { return GenerateSelectAllSQL(testUsersTable(), opt) }, `SELECT id, name FROM "users"`},
+	}
+
+	for _, tt := range tests {
+		sql, err := tt.generate()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+}
+
+func Test_GenerateSelectOneSQL_SoftDelete(t *testing.T) {
+	table := testUsersTable()
+	deletedAt := testColumn("deleted_at", "TIMESTAMPTZ", 3)
+	deletedAt.nullable = true
+	table.columns = append(table.columns, deletedAt)
+
+	sql, err := GenerateSelectOneSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id = $1 AND deleted_at IS NULL`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectOneSQL(table, false, SQLOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectOneStatement(t *testing.T) {
+	tenantID := testColumn("tenant_id", "UUID", 0)
+	tenantID.isPrimaryKey = true
+	id := testColumn("id", "INT4", 1)
+	id.isPrimaryKey = true
+	accounts := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "accounts",
+		columns:   []*columnMeta{testColumn("name", "TEXT", 0), tenantID, id},
+	}
+
+	stmt, err := GenerateSelectOneStatement(accounts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "accounts" WHERE tenant_id = $1 AND id = $2`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if len(stmt.Params) != 2 || stmt.Params[0].Column != "tenant_id" || stmt.Params[1].Column != "id" {
+		t.Errorf("unexpected params %v", stmt.Params)
+	}
+
+	again, err := GenerateSelectOneStatement(accounts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Name != stmt.Name {
+		t.Errorf("expect deterministic name: %s, but got %s", stmt.Name, again.Name)
+	}
+
+	// same column shape on a different table must not collide
+	other := *accounts
+	other.tableName = "Accounts"
+	otherStmt, err := GenerateSelectOneStatement(&other, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherStmt.Name == stmt.Name {
+		t.Errorf("expect distinct names for tables %s and %s, but got %s", accounts.tableName, other.tableName, stmt.Name)
+	}
+}
+
+func Test_GenerateSelectOneSQLCaseInsensitiveKeys(t *testing.T) {
+	tenantID := testColumn("tenant_id", "INT4", 0)
+	tenantID.isPrimaryKey = true
+	username := testColumn("username", "VARCHAR", 1)
+	username.isPrimaryKey = true
+	logins := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "logins",
+		columns:   []*columnMeta{tenantID, username},
+	}
+
+	sql, err := GenerateSelectOneSQL(logins, false, SQLOptions{CaseInsensitiveKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "logins" WHERE tenant_id = $1 AND LOWER(username) = LOWER($2)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateInsertReturningIDSQL(t *testing.T) {
+	sql, err := GenerateInsertReturningIDSQL(testUsersTable(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (name, email) VALUES ($1, $2) RETURNING id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	mssqlUsers := testUsersTable()
+	mssqlUsers.sqlType = "mssql"
+	sql, err = GenerateInsertReturningIDSQL(mssqlUsers, false, SQLOptions{Include: func(col ColumnMeta) bool { return col.Name() != "id" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO [users] (name, email) OUTPUT INSERTED.id VALUES (@p1, @p2)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateInsertReturningIDSQL(testUsersTable(), false, SQLOptions{Returning: []string{"email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "users" (name, email) VALUES ($1, $2) RETURNING id, email`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateInsertReturningIDSQL(testUsersTable(), false, SQLOptions{Returning: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "users" (name, email) VALUES ($1, $2) RETURNING *`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	noAutoIncrement := testUsersTable()
+	noAutoIncrement.columns[0].isAutoIncrement = false
+	_, err = GenerateInsertReturningIDSQL(noAutoIncrement, false)
+	if err == nil {
+		t.Errorf("expected error for table without an auto increment column")
+	}
+}
+
+func Test_GenerateUpdateSQLJSONMerge(t *testing.T) {
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	profiles := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "profiles",
+		columns:   []*columnMeta{id, testColumn("name", "TEXT", 1), testColumn("attrs", "JSONB", 2)},
+	}
+
+	sql, err := GenerateUpdateSQL(profiles, false, SQLOptions{JSONMergeColumns: []string{"attrs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `UPDATE "profiles" SET name = $1, attrs = attrs || $2::jsonb WHERE id = $3`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateUpdateSQL(profiles, false, SQLOptions{JSONMergeColumns: []string{"name"}})
+	if err == nil {
+		t.Errorf("expected error for non json merge column")
+	}
+}
+
+func Test_GenerateSelectOneSQLLock(t *testing.T) {
+	mysqlUsers := testUsersTable()
+	mysqlUsers.sqlType = "mysql"
+	sqliteUsers := testUsersTable()
+	sqliteUsers.sqlType = "sqlite3"
+
+	tests := []struct {
+		name     string
+		table    *dbTableMeta
+		mode     LockMode
+		expected string
+	}{
+		{"postgres share", testUsersTable(), LockForShare, `SELECT * FROM "users" WHERE id = $1 FOR SHARE`},
+		{"postgres no key update", testUsersTable(), LockForNoKeyUpdate, `SELECT * FROM "users" WHERE id = $1 FOR NO KEY UPDATE`},
+		{"mysql share", mysqlUsers, LockForShare, "SELECT * FROM `users` WHERE id = ? LOCK IN SHARE MODE"},
+	}
+
+	for _, tt := range tests {
+		sql, err := GenerateSelectOneSQL(tt.table, false, SQLOptions{Lock: tt.mode})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+
+	_, err := GenerateSelectOneSQL(mysqlUsers, false, SQLOptions{Lock: LockForKeyShare})
+	if err == nil {
+		t.Errorf("expected error for lock mode unsupported by mysql")
+	}
+
+	_, err = GenerateSelectOneSQL(sqliteUsers, false, SQLOptions{Lock: LockForUpdate})
+	if err == nil {
+		t.Errorf("expected error for lock mode unsupported by sqlite")
+	}
+}
+
+func Test_GenerateSQLCastParams(t *testing.T) {
+	id := testColumn("id", "UUID", 0)
+	id.isPrimaryKey = true
+	tokens := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "tokens",
+		columns:   []*columnMeta{id, testColumn("hits", "INT8", 1), testColumn("deleted_at", "TIMESTAMPTZ", 2)},
+	}
+	opt := SQLOptions{CastParams: true, IncludeDeleted: true}
+
+	generators := []struct {
+		name     string
+		generate func() (string, error)
+		expected string
+	}{
+		{"select one", func() (string, error) { return GenerateSelectOneSQL(tokens, false, opt) },
+			`SELECT * FROM "tokens" WHERE id = $1::uuid`},
+		{"update", func() (string, error) { return GenerateUpdateSQL(tokens, false, opt) },
+			`UPDATE "tokens" SET hits = $1::int8, deleted_at = $2::timestamptz WHERE id = $3::uuid`},
+		{"hard delete", func() (string, error) { return GenerateHardDeleteSQL(tokens, false, opt) },
+			`DELETE FROM "tokens" where id = $1::uuid`},
+		{"soft delete", func() (string, error) { return GenerateSoftDeleteSQL(tokens, false, opt) },
+			`UPDATE "tokens" set deleted_at = $1::timestamptz WHERE id = $2::uuid`},
+		{"named", func() (string, error) { return GenerateSelectOneSQL(tokens, true, opt) },
+			`SELECT * FROM "tokens" WHERE id = @where_id_1`},
+	}
+
+	for _, tt := range generators {
+		sql, err := tt.generate()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+}
+
+func Test_GenerateHardDeleteSQLCompositeKey(t *testing.T) {
+	tenantID := testColumn("tenant_id", "INT4", 0)
+	tenantID.isPrimaryKey = true
+	id := testColumn("id", "INT4", 1)
+	id.isPrimaryKey = true
+	accounts := &dbTableMeta{sqlType: "postgres", tableName: "accounts", columns: []*columnMeta{tenantID, id}}
+
+	sql, err := GenerateHardDeleteSQL(accounts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `DELETE FROM "accounts" where tenant_id = $1 AND id = $2`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectMultiSQL(t *testing.T) {
+	sql, err := GenerateSelectMultiSQL(testUsersTable(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id = ANY($1::int4[])`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	mysqlUsers := testUsersTable()
+	mysqlUsers.sqlType = "mysql"
+	sql, err = GenerateSelectMultiSQL(mysqlUsers, false, SQLOptions{IDCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT * FROM `users` WHERE id IN (?, ?, ?)"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectMultiSQL(mysqlUsers, false)
+	if err == nil {
+		t.Errorf("expected error for missing id count")
+	}
+
+	_, err = GenerateSelectMultiSQL(mysqlUsers, false, SQLOptions{IDCount: 3, CastParams: true})
+	if err == nil {
+		t.Errorf("expect error for parameter casts on mysql")
+	}
+}
+
+func Test_GenerateInsertSQLAutoIncrementColumns(t *testing.T) {
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	seq := testColumn("seq", "INT8", 1)
+	seq.isAutoIncrement = true
+	events := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "events",
+		columns:   []*columnMeta{id, seq, testColumn("payload", "TEXT", 2)},
+	}
+
+	sql, err := GenerateInsertSQL(events, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the non primary key auto increment column is left to the database
+	expected := `INSERT INTO "events" (id, payload) VALUES ($1, $2)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	id.isAutoIncrement = true
+	sql, err = GenerateInsertSQL(events, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO "events" (payload) VALUES (@payload)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectOneSQLComputedColumns(t *testing.T) {
+	opt := SQLOptions{
+		TableAlias: "u",
+		Computed:   []ComputedColumn{{Expr: "length(u.name)", Alias: "name_length"}},
+	}
+
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT u.*, length(u.name) AS name_length FROM "users" u WHERE u.id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []ComputedColumn{
+		{Expr: "lower(email)", Alias: "Email"},
+		{Expr: "1", Alias: "one; DROP TABLE users"},
+		{Expr: "", Alias: "empty"},
+	}
+	for _, computed := range invalid {
+		_, err = GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Computed: []ComputedColumn{computed}})
+		if err == nil {
+			t.Errorf("expected error for computed column %v", computed)
+		}
+	}
+}
+
+func Test_GenerateUpdateSQLNothingToUpdate(t *testing.T) {
+	userID := testColumn("user_id", "INT4", 0)
+	userID.isPrimaryKey = true
+	groupID := testColumn("group_id", "INT4", 1)
+	groupID.isPrimaryKey = true
+	memberships := &dbTableMeta{sqlType: "postgres", tableName: "memberships", columns: []*columnMeta{userID, groupID}}
+
+	_, err := GenerateUpdateSQL(memberships, false)
+	if !errors.Is(err, ErrNothingToUpdate) {
+		t.Errorf("expect: %v, but got %v", ErrNothingToUpdate, err)
+	}
+}
+
+func Test_GenerateSelectSQLMixedTypeCompositeKey(t *testing.T) {
+	tenantID := testColumn("tenant_id", "UUID", 0)
+	tenantID.isPrimaryKey = true
+	code := testColumn("code", "TEXT", 1)
+	code.isPrimaryKey = true
+	products := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "products",
+		columns:   []*columnMeta{testColumn("name", "TEXT", 0), tenantID, code},
+	}
+
+	stmt, err := GenerateSelectOneStatement(products, false, SQLOptions{CastParams: true, CaseInsensitiveKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "products" WHERE tenant_id = $1::uuid AND LOWER(code) = LOWER($2::text)`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if len(stmt.Params) != 2 || !stmt.Params[0].IsUUID || stmt.Params[1].IsUUID {
+		t.Errorf("unexpected params %v", stmt.Params)
+	}
+
+	sql, err := GenerateSelectMultiSQL(products, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "products" WHERE tenant_id = ANY($1::uuid[]) AND code = ANY($2::text[])`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectOneSQLAsOf(t *testing.T) {
+	mssqlUsers := testUsersTable()
+	mssqlUsers.sqlType = "mssql"
+
+	sql, err := GenerateSelectOneSQL(mssqlUsers, false, SQLOptions{AsOf: true, TableAlias: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT u.* FROM [users] FOR SYSTEM_TIME AS OF @p1 AS u WHERE u.id = @p2`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	mssqlUsers.indexes = []Index{{Name: "idx_users_email", Columns: []string{"email"}}}
+	sql, err = GenerateSelectOneSQL(mssqlUsers, false, SQLOptions{AsOf: true, TableAlias: "u", Partition: "users_2024", IndexHint: "idx_users_email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT u.* FROM [users_2024] FOR SYSTEM_TIME AS OF @p1 AS u WITH (INDEX([idx_users_email])) WHERE u.id = @p2`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{AsOf: true})
+	if err == nil {
+		t.Errorf("expected error for postgres")
+	}
+}
+
+func Test_ProjectedColumns(t *testing.T) {
+	table := testUsersTable()
+
+	stmt, err := GenerateSelectOneStatement(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"id", "name", "email"}
+	if !reflect.DeepEqual(stmt.Columns, expected) {
+		t.Errorf("expect: %v, but got %v", expected, stmt.Columns)
+	}
+
+	opt := SQLOptions{
+		Columns:  []string{"email", "id"},
+		Computed: []ComputedColumn{{Expr: "lower(name)", Alias: "name_key"}},
+	}
+	columns, err := ProjectedColumns(table, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{"email", "id", "name_key"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expect: %v, but got %v", expected, columns)
+	}
+
+	_, err = ProjectedColumns(table, SQLOptions{Columns: []string{"missing"}})
+	if err == nil {
+		t.Errorf("expect error for unknown column")
+	}
+}
+
+func Test_GenerateHardDeleteSQLReturning(t *testing.T) {
+	table := testUsersTable()
+
+	sql, err := GenerateHardDeleteSQL(table, false, SQLOptions{Returning: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `DELETE FROM "users" where id = $1 RETURNING *`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mssql"
+	sql, err = GenerateHardDeleteSQL(table, false, SQLOptions{Returning: []string{"id", "email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `DELETE FROM [users] OUTPUT DELETED.id, DELETED.email where id = @p1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateHardDeleteSQL(table, false, SQLOptions{Returning: []string{"missing"}})
+	if err == nil {
+		t.Errorf("expect error for unknown returning column")
+	}
+
+	table.sqlType = "mysql"
+	_, err = GenerateHardDeleteSQL(table, false, SQLOptions{Returning: []string{"*"}})
+	if err == nil {
+		t.Errorf("expect error for mysql returning")
+	}
+}
+
+func Test_GenerateSelectOneStatementTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3))
+
+	stmt, err := GenerateSelectOneStatement(table, false, SQLOptions{TenantColumn: "tenant_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id = $1 AND tenant_id = $2`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if stmt.TenantParam != 2 || stmt.Params[1].Column != "tenant_id" {
+		t.Errorf("expect: tenant parameter 2, but got %d %v", stmt.TenantParam, stmt.Params)
+	}
+
+	_, err = GenerateSelectOneStatement(testUsersTable(), false, SQLOptions{TenantColumn: "tenant_id"})
+	if err == nil {
+		t.Errorf("expect error for missing tenant column")
+	}
+}
+
+func Test_GenerateInsertSQLColumnOrder(t *testing.T) {
+	table := testUsersTable()
+
+	sql, err := GenerateInsertSQL(table, false, SQLOptions{ColumnOrder: []string{"email", "name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (email, name) VALUES ($1, $2)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := [][]string{
+		{"email"},
+		{"email", "name", "email"},
+		{"email", "name", "id"},
+	}
+	for _, order := range invalid {
+		_, err = GenerateInsertSQL(table, false, SQLOptions{ColumnOrder: order})
+		if err == nil {
+			t.Errorf("expect error for column order %v", order)
+		}
+	}
+}
+
+func Test_GenerateSelectOneSQLCoalesceNulls(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("score", "INT4", 3), testColumn("joined_at", "TIMESTAMP", 4))
+	table.columns[3].nullable = true
+	table.columns[4].nullable = true
+
+	opt := SQLOptions{
+		Columns:          []string{"id", "email", "score", "joined_at"},
+		CoalesceNulls:    true,
+		CoalesceDefaults: map[string]string{"joined_at": "'epoch'"},
+	}
+	sql, err := GenerateSelectOneSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT id, COALESCE(email, '') AS email, COALESCE(score, 0) AS score, COALESCE(joined_at, 'epoch') AS joined_at FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []SQLOptions{
+		{CoalesceNulls: true},
+		{Columns: []string{"joined_at"}, CoalesceNulls: true},
+		{Columns: []string{"email"}, CoalesceNulls: true, CoalesceDefaults: map[string]string{"missing": "0"}},
+	}
+	for _, opt := range invalid {
+		_, err = GenerateSelectOneSQL(table, false, opt)
+		if err == nil {
+			t.Errorf("expect error for coalesce options %v", opt)
+		}
+	}
+}
+
+func Test_GenerateSQLMixedCaseTableName(t *testing.T) {
+	table := testUsersTable()
+	table.tableName = "MyTable"
+
+	if table.TableName() != "MyTable" {
+		t.Fatalf("expect: MyTable, but got %s", table.TableName())
+	}
+
+	generators := []struct {
+		name     string
+		generate func() (string, error)
+		expected string
+	}{
+		{"select one", func() (string, error) { return GenerateSelectOneSQL(table, false) }, `SELECT * FROM "MyTable" WHERE id = $1`},
+		{"insert", func() (string, error) { return GenerateInsertSQL(table, false) }, `INSERT INTO "MyTable" (name, email) VALUES ($1, $2)`},
+		{"update", func() (string, error) { return GenerateUpdateSQL(table, false) }, `UPDATE "MyTable" SET name = $1, email = $2 WHERE id = $3`},
+		{"hard delete", func() (string, error) { return GenerateHardDeleteSQL(table, false) }, `DELETE FROM "MyTable" where id = $1`},
+		{"rename", func() (string, error) { return GenerateRenameTableSQL(table, "OtherTable") }, `ALTER TABLE "MyTable" RENAME TO "OtherTable"`},
+	}
+
+	for _, tt := range generators {
+		sql, err := tt.generate()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+}
+
+func Test_GenerateSoftDeleteSQLDeletedBy(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("deleted_at", "TIMESTAMPTZ", 3), testColumn("deleted_by", "INT4", 4))
+
+	sql, err := GenerateSoftDeleteSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `UPDATE "users" set deleted_at = $1, deleted_by = $2 WHERE id = $3`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.columns[3].name = "removed_on"
+	table.columns[4].name = "removed_by"
+	opt := SQLOptions{SoftDeleteColumn: "removed_on", DeletedByColumn: "removed_by"}
+	sql, err = GenerateSoftDeleteSQL(table, true, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `UPDATE "users" set removed_on = @upd_removed_on_1, removed_by = @upd_removed_by_2 WHERE id = @id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSoftDeleteSQL(table, false)
+	if err == nil {
+		t.Errorf("expect error for missing deleted at column")
+	}
+}
+
+func Test_GenerateInsertSQLArrayCast(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tags", "TEXT[]", 3), testColumn("scores", "_INT4", 4))
+
+	sql, err := GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (name, email, tags, scores) VALUES ($1, $2, $3::text[], $4::int4[])`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mysql"
+	sql, err = GenerateInsertSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "INSERT INTO `users` (name, email, tags, scores) VALUES (?, ?, ?, ?)"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateUpdateChangedSQL(t *testing.T) {
+	table := testUsersTable()
+	before := map[string]interface{}{"id": 7, "name": "ann", "email": nil}
+	after := map[string]interface{}{"id": 7, "name": "ann", "email": "ann@example.com"}
+
+	stmt, err := GenerateUpdateChangedSQL(table, before, after, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `UPDATE "users" SET email = $1 WHERE id = $2`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if len(stmt.Params) != 2 || stmt.Params[0].Column != "email" || stmt.Params[1].Column != "id" {
+		t.Errorf("expect email and id params, but got %v", stmt.Params)
+	}
+
+	_, err = GenerateUpdateChangedSQL(table, before, before, false)
+	if !errors.Is(err, ErrNothingToUpdate) {
+		t.Errorf("expect: %v, but got %v", ErrNothingToUpdate, err)
+	}
+
+	_, err = GenerateUpdateChangedSQL(table, before, map[string]interface{}{"missing": 1}, false)
+	if err == nil {
+		t.Errorf("expect error for unknown column")
+	}
+}
+
+func Test_GenerateUpdateColumnsSQL(t *testing.T) {
+	table := testUsersTable()
+
+	stmt, err := GenerateUpdateColumnsSQL(table, []string{"email"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `UPDATE "users" SET email = $1 WHERE id = $2 RETURNING id, email`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if !reflect.DeepEqual(stmt.Columns, []string{"id", "email"}) {
+		t.Errorf("expect: [id email], but got %v", stmt.Columns)
+	}
+
+	stmt, err = GenerateUpdateColumnsSQL(table, []string{"email"}, false, SQLOptions{Returning: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stmt.Columns, []string{"id", "name", "email"}) {
+		t.Errorf("expect: [id name email], but got %v", stmt.Columns)
+	}
+
+	mssqlUsers := testUsersTable()
+	mssqlUsers.sqlType = "mssql"
+	stmt, err = GenerateUpdateColumnsSQL(mssqlUsers, []string{"name"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `UPDATE [users] SET name = @p1 OUTPUT INSERTED.id, INSERTED.name WHERE id = @p2`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	mysqlUsers := testUsersTable()
+	mysqlUsers.sqlType = "mysql"
+	stmt, err = GenerateUpdateColumnsSQL(mysqlUsers, []string{"name"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmt.Columns) != 0 {
+		t.Errorf("expect no returned columns, but got %v", stmt.Columns)
+	}
+
+	_, err = GenerateUpdateColumnsSQL(table, nil, false)
+	if !errors.Is(err, ErrNothingToUpdate) {
+		t.Errorf("expect: %v, but got %v", ErrNothingToUpdate, err)
+	}
+
+	_, err = GenerateUpdateColumnsSQL(table, []string{"id"}, false)
+	if err == nil {
+		t.Errorf("expect error for primary key update column")
+	}
+}
+
+func Test_GenerateInsertStatementReturnGenerated(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("created_at", "TIMESTAMPTZ", 3))
+	table.columns[3].defaultVal = "now()"
+	opt := SQLOptions{
+		ReturnGenerated: true,
+		Include:         func(col ColumnMeta) bool { return col.DefaultValue() == "" },
+	}
+
+	stmt, err := GenerateInsertStatement(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (name, email) VALUES ($1, $2) RETURNING id, created_at`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if !reflect.DeepEqual(stmt.Columns, []string{"id", "created_at"}) {
+		t.Errorf("expect: [id created_at], but got %v", stmt.Columns)
+	}
+
+	table.sqlType = "mssql"
+	stmt, err = GenerateInsertStatement(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `INSERT INTO [users] (name, email) OUTPUT INSERTED.id, INSERTED.created_at VALUES (@p1, @p2)`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	table.sqlType = "mysql"
+	_, err = GenerateInsertStatement(table, false, opt)
+	if err == nil {
+		t.Errorf("expect error for mysql returning")
+	}
+}
+
+func Test_GenerateSelectByColumnsSQLPredicateExpressions(t *testing.T) {
+	opt := SQLOptions{PredicateExpressions: map[string]string{"email": "LOWER(?)"}}
+	sql, err := GenerateSelectByColumnsSQL(testUsersTable(), []string{"email"}, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE LOWER(email) = LOWER($1)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectByColumnsSQL(testUsersTable(), []string{"email"}, true, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE LOWER(email) = LOWER(@where_email_1)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectByColumnsSQL(testUsersTable(), []string{"email"}, false, SQLOptions{PredicateExpressions: map[string]string{"email": "LOWER(email)"}})
+	if err == nil {
+		t.Errorf("expect error for a predicate expression without the operand")
+	}
+
+	_, err = GenerateSelectByColumnsSQL(testUsersTable(), []string{"email"}, false, SQLOptions{PredicateExpressions: map[string]string{"missing": "LOWER(?)"}})
+	if err == nil {
+		t.Errorf("expect error for a predicate expression of an unknown column")
+	}
+}
+
+func Test_GenerateSelectOneDualSQL(t *testing.T) {
+	positional, named, err := GenerateSelectOneDualSQL(testUsersTable(), SQLOptions{TenantColumn: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id = $1 AND name = $2`
+	if positional != expected {
+		t.Errorf("expect: %s, but got %s", expected, positional)
+	}
+
+	expected = `SELECT * FROM "users" WHERE id = @where_id_1 AND name = @where_name_2`
+	if named != expected {
+		t.Errorf("expect: %s, but got %s", expected, named)
+	}
+}
+
+func Test_GenerateSelectOneSQLIndexHint(t *testing.T) {
+	table := testUsersTable()
+	table.indexes = []Index{{Name: "idx_users_email", Columns: []string{"email"}}}
+
+	sql, err := GenerateSelectOneSQL(table, false, SQLOptions{IndexHint: "idx_users_email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `/*+ IndexScan(users idx_users_email) */ SELECT * FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectAllSQL(table, SQLOptions{IndexHint: "idx_users_email", TableAlias: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `/*+ IndexScan(u idx_users_email) */ SELECT u.* FROM "users" u`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mysql"
+	sql, err = GenerateSelectOneSQL(table, false, SQLOptions{IndexHint: "idx_users_email", TableAlias: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT u.* FROM `users` u USE INDEX (`idx_users_email`) WHERE u.id = ?"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectOneSQL(table, false, SQLOptions{IndexHint: "idx_missing"})
+	if err == nil {
+		t.Errorf("expect error for an unknown index hint")
+	}
+}
+
+func Test_GenerateInsertSQLColumnValueAlignment(t *testing.T) {
+	id := testColumn("id", "INT4", 0)
+	id.isPrimaryKey = true
+	id.isAutoIncrement = true
+	seq := testColumn("seq", "INT8", 2)
+	seq.isAutoIncrement = true
+	events := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "events",
+		columns: []*columnMeta{id, testColumn("kind", "TEXT", 1), seq, testColumn("secret", "TEXT", 3),
+			testColumn("payload", "TEXT", 4)},
+	}
+
+	options := []SQLOptions{
+		{},
+		{Include: func(col ColumnMeta) bool { return col.Name() != "secret" }},
+		{ColumnOrder: []string{"payload", "secret", "kind"}},
+	}
+	for _, opt := range options {
+		stmt, err := GenerateInsertStatement(events, false, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lists := strings.SplitN(stmt.SQL, ") VALUES (", 2)
+		if len(lists) != 2 {
+			t.Fatalf("unexpected insert %s", stmt.SQL)
+		}
+		columns := strings.Split(lists[0][strings.Index(lists[0], "(")+1:], ", ")
+		values := strings.Split(strings.TrimSuffix(lists[1], ")"), ", ")
+
+		if len(columns) != len(values) || len(columns) != len(stmt.Params) {
+			t.Errorf("expect: %d values and params, but got %d values and %d params in %s", len(columns), len(values), len(stmt.Params), stmt.SQL)
+			continue
+		}
+
+		for i, value := range values {
+			if expected := fmt.Sprintf("$%d", i+1); value != expected {
+				t.Errorf("expect: %s, but got %s in %s", expected, value, stmt.SQL)
+			}
+			if stmt.Params[i].Name != columns[i] {
+				t.Errorf("expect: %s, but got %s", columns[i], stmt.Params[i].Name)
+			}
+		}
+	}
+}
+
+func Test_GenerateSelectByColumnsSQLBoolPredicates(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("active", "BOOL", 3))
+	opt := SQLOptions{BoolPredicates: map[string]bool{"active": true}}
+
+	sql, err := GenerateSelectByColumnsSQL(table, []string{"email"}, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE email = $1 AND active = TRUE`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mssql"
+	table.columns[3] = testColumn("active", "BIT", 3)
+	sql, err = GenerateSelectOneSQL(table, false, SQLOptions{BoolPredicates: map[string]bool{"active": false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM [users] WHERE id = @p1 AND active = 0`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectOneSQL(table, false, SQLOptions{BoolPredicates: map[string]bool{"name": true}})
+	if err == nil {
+		t.Errorf("expect error for a boolean predicate on a text column")
+	}
+}
+
+func Test_GenerateSelectByColumnsSQLCollations(t *testing.T) {
+	opt := SQLOptions{Collations: map[string]string{"name": "en_US"}}
+	sql, err := GenerateSelectByColumnsSQL(testUsersTable(), []string{"name"}, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE name COLLATE "en_US" = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []SQLOptions{
+		{Collations: map[string]string{"id": "en_US"}},
+		{Collations: map[string]string{"missing": "en_US"}},
+		{Collations: map[string]string{"name": `en_US" = '' OR "`}},
+	}
+	for _, opt := range invalid {
+		_, err = GenerateSelectByColumnsSQL(testUsersTable(), []string{"name"}, false, opt)
+		if err == nil {
+			t.Errorf("expect error for collations %v", opt.Collations)
+		}
+	}
+}
+
+func Test_GenerateSelectAllSQLDefaultMaxRows(t *testing.T) {
+	DefaultMaxRows = 1000
+	defer func() { DefaultMaxRows = 0 }()
+
+	sql, err := GenerateSelectAllSQL(testUsersTable(), SQLOptions{OrderBy: []OrderColumn{{Column: "name"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" ORDER BY name, id LIMIT 1000`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectAllSQL(testUsersTable(), SQLOptions{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table := testUsersTable()
+	table.sqlType = "mssql"
+	sql, err = GenerateSelectAllSQL(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT TOP (1000) * FROM [users]`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectOneSQLOnly(t *testing.T) {
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Only: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM ONLY "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table := testUsersTable()
+	table.sqlType = "mysql"
+	sql, err = GenerateSelectOneSQL(table, false, SQLOptions{Only: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT * FROM `users` WHERE id = ?"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
+
+func Test_GenerateSelectOneSQLPartition(t *testing.T) {
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Partition: "users_2024_01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users_2024_01" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{Partition: `users"; DROP TABLE users; --`})
+	if err == nil {
+		t.Errorf("expect error for an unsafe partition name")
+	}
+}
+
+func Test_GenerateSelectOneByUniqueSQLTenantScoped(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "INT4", 3))
+	table.indexes = []Index{
+		{Name: "idx_users_name", Columns: []string{"name"}},
+		{Name: "users_tenant_id_email_key", Columns: []string{"tenant_id", "email"}, Unique: true},
+	}
+
+	sql, err := GenerateSelectOneByUniqueSQL(table, "users_tenant_id_email_key", false, SQLOptions{TenantColumn: "tenant_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE tenant_id = $1 AND email = $2`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectOneByUniqueSQL(table, "users_tenant_id_email_key", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE tenant_id = @where_tenant_id_1 AND email = @where_email_2`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectOneByUniqueSQL(table, "idx_users_name", false)
+	if err == nil {
+		t.Errorf("expect error for a non unique index")
+	}
+}
+
+func Test_GenerateUpdateFromSQL(t *testing.T) {
+	staging := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "users_staging",
+		columns:   []*columnMeta{testColumn("id", "INT4", 0), testColumn("full_name", "VARCHAR", 1), testColumn("mail", "VARCHAR", 2)},
+	}
+
+	sql, err := GenerateUpdateFromSQL(testUsersTable(), staging, []string{"id"}, map[string]string{"email": "mail", "name": "full_name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `UPDATE "users" SET name = "users_staging".full_name, email = "users_staging".mail FROM "users_staging" WHERE "users".id = "users_staging".id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []struct {
+		joinCols []string
+		setMap   map[string]string
+	}{
+		{[]string{"name"}, map[string]string{"email": "mail"}},
+		{[]string{"id"}, map[string]string{"missing": "mail"}},
+		{[]string{"id"}, map[string]string{"email": "missing"}},
+	}
+	for _, tt := range invalid {
+		_, err = GenerateUpdateFromSQL(testUsersTable(), staging, tt.joinCols, tt.setMap)
+		if err == nil {
+			t.Errorf("expect error for join columns %v and set %v", tt.joinCols, tt.setMap)
+		}
+	}
+}
+
+func Test_GenerateSelectOneSQLRoutingComment(t *testing.T) {
+	opt := SQLOptions{RoutingComment: "replica"}
+	sql, err := GenerateSelectOneSQL(testUsersTable(), false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `/* replica */ SELECT * FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectAllSQL(testUsersTable(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `/* replica */ SELECT * FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	generators := map[string]func() (string, error){
+		"page":        func() (string, error) { return GenerateSelectPageSQL(testUsersTable(), false, opt) },
+		"cursor page": func() (string, error) { return GenerateCursorPageSQL(testUsersTable(), Forward, false, opt) },
+		"key range":   func() (string, error) { return GenerateSelectKeyRangeSQL(testUsersTable(), false, opt) },
+		"csv":         func() (string, error) { return GenerateSelectForCSVSQL(testUsersTable(), nil, opt) },
+		"cursor":      func() (string, error) { return GenerateDeclareCursorSQL(testUsersTable(), "users_cursor", opt) },
+	}
+	for name, generate := range generators {
+		sql, err := generate()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.HasPrefix(sql, "/* replica */ ") || strings.Count(sql, "replica") != 1 {
+			t.Errorf("%s expect routing comment, but got %s", name, sql)
+		}
+	}
+
+	_, err = GenerateUpdateSQL(testUsersTable(), false, opt)
+	if err == nil {
+		t.Errorf("expect error for a routing comment on an update")
+	}
+
+	_, err = GenerateInsertSQL(testUsersTable(), false, opt)
+	if err == nil {
+		t.Errorf("expect error for a routing comment on an insert")
+	}
+
+	_, err = GenerateSelectOneSQL(testUsersTable(), false, SQLOptions{RoutingComment: "replica */ DELETE FROM users; /*"})
+	if err == nil {
+		t.Errorf("expect error for an invalid routing comment")
+	}
+}
+
+func Test_MutationsRefuseTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("deleted_at", "TIMESTAMP", 4))
+
+	_, err := GenerateSoftDeleteSQL(table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opt := range []SQLOptions{{TenantColumn: "tenant_id"}, {DetectTenant: true}} {
+		generators := map[string]func() error{
+			"update": func() error { _, err := GenerateUpdateSQL(table, false, opt); return err },
+			"update columns": func() error {
+				_, err := GenerateUpdateColumnsSQL(table, []string{"name"}, false, opt)
+				return err
+			},
+			"hard delete": func() error { _, err := GenerateHardDeleteSQL(table, false, opt); return err },
+			"soft delete": func() error { _, err := GenerateSoftDeleteSQL(table, false, opt); return err },
+			"insert":      func() error { _, err := GenerateInsertSQL(table, false, opt); return err },
+			"upsert":      func() error { _, err := GenerateUpsertSQL(table, false, opt); return err },
+		}
+		for name, generate := range generators {
+			if generate() == nil {
+				t.Errorf("expect error for a tenant scoped %s", name)
+			}
+		}
+	}
+}
+
+func Test_GenerateDeleteWithAuditSQL(t *testing.T) {
+	audit := &dbTableMeta{
+		sqlType:   "postgres",
+		tableName: "users_audit",
+		columns: []*columnMeta{testColumn("audit_id", "INT8", 0), testColumn("id", "INT4", 1), testColumn("name", "VARCHAR", 2),
+			testColumn("email", "VARCHAR", 3), testColumn("deleted_at", "TIMESTAMPTZ", 4)},
+	}
+
+	sql, err := GenerateDeleteWithAuditSQL(testUsersTable(), audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `WITH deleted AS (DELETE FROM "users" WHERE id = $1 RETURNING *) INSERT INTO "users_audit" (id, name, email) SELECT id, name, email FROM deleted`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	audit.columns = audit.columns[:3]
+	_, err = GenerateDeleteWithAuditSQL(testUsersTable(), audit)
+	if err == nil {
+		t.Errorf("expect error for an audit table missing a column")
+	}
+}
+
+func Test_GenerateSelectChangedSQL(t *testing.T) {
+	stmt, err := GenerateSelectChangedSQL(testUsersTable(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT 1 FROM "users" WHERE id = $1 AND (name IS DISTINCT FROM $2 OR email IS DISTINCT FROM $3)`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	if len(stmt.Params) != 3 || stmt.Params[2].Column != "email" {
+		t.Errorf("expect: params id, name, email, but got %v", stmt.Params)
+	}
+
+	table := testUsersTable()
+	table.sqlType = "mssql"
+	stmt, err = GenerateSelectChangedSQL(table, false, SQLOptions{Include: func(col ColumnMeta) bool { return col.Name() == "email" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT 1 FROM [users] WHERE id = @p1 AND ((email <> @p2 OR (email IS NULL AND @p2 IS NOT NULL) OR (email IS NOT NULL AND @p2 IS NULL)))`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+}
+
+func Test_GenerateInsertSQLDuplicateColumn(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("email", "VARCHAR", 3))
+
+	_, err := GenerateInsertSQL(table, false)
+	if !errors.Is(err, ErrDuplicateColumn) {
+		t.Errorf("expect: %v, but got %v", ErrDuplicateColumn, err)
+	}
+
+	_, err = GenerateUpsertSQL(table, false)
+	if !errors.Is(err, ErrDuplicateColumn) {
+		t.Errorf("expect: %v, but got %v", ErrDuplicateColumn, err)
+	}
+}
+
+func Test_GenerateSelectOneStatementTimeZone(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("created_at", "TIMESTAMPTZ", 3), testColumn("birthday", "DATE", 4))
+	opt := SQLOptions{TimeZone: true, Columns: []string{"id", "created_at", "birthday"}}
+
+	stmt, err := GenerateSelectOneStatement(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT id, created_at AT TIME ZONE $1 AS created_at, birthday FROM "users" WHERE id = $2`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	if len(stmt.Params) != 2 || stmt.Params[0].Name != "tz" || stmt.Params[1].Column != "id" {
+		t.Errorf("expect: params tz, id, but got %v", stmt.Params)
+	}
+
+	table.sqlType = "mysql"
+	table.columns[3] = testColumn("created_at", "TIMESTAMP", 3)
+	stmt, err = GenerateSelectOneStatement(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT id, CONVERT_TZ(created_at, @@session.time_zone, ?) AS created_at, birthday FROM `users` WHERE id = ?"
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+
+	_, err = GenerateSelectOneSQL(table, false, SQLOptions{TimeZone: true})
+	if err == nil {
+		t.Errorf("expect error for time zone conversion of a * projection")
+	}
+
+	_, err = GenerateSelectAllSQL(table, opt)
+	if err == nil {
+		t.Errorf("expect error for time zone conversion when selecting all records")
+	}
+}
+
+func Test_GenerateInsertSQLSequences(t *testing.T) {
+	table := testUsersTable()
+	table.columns[0].isAutoIncrement = false
+
+	sql, err := GenerateInsertSQL(table, false, SQLOptions{Sequences: map[string]string{"id": "users_id_seq"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `INSERT INTO "users" (id, name, email) VALUES (nextval('users_id_seq'), $1, $2)`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	invalid := []map[string]string{
+		{"name": "users_name_seq"},
+		{"id": "users_id_seq'); DROP TABLE users; --"},
+	}
+	for _, sequences := range invalid {
+		_, err = GenerateInsertSQL(table, false, SQLOptions{Sequences: sequences})
+		if err == nil {
+			t.Errorf("expect error for sequences %v", sequences)
+		}
+	}
+}
+
+func Test_OperationComment(t *testing.T) {
+	table := testUsersTable()
+	opt := SQLOptions{OperationComment: true}
+
+	sql, err := GenerateSelectOneSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `/* gen table=users op=select_one */ SELECT * FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateUpdateSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sql, "/* gen table=users op=update */ UPDATE ") {
+		t.Errorf("expect update operation comment, but got %s", sql)
+	}
+
+	sql, err = GenerateSelectAllSQL(table, SQLOptions{OperationComment: true, RoutingComment: "replica"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `/* replica */ /* gen table=users op=select_all */ SELECT * FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectPageSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sql, "/* gen table=users op=select_page */ SELECT ") {
+		t.Errorf("expect page operation comment, but got %s", sql)
+	}
+
+	sql, err = GenerateDeclareCursorSQL(table, "users_export", opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `/* gen table=users op=declare_cursor */ DECLARE users_export NO SCROLL CURSOR FOR SELECT * FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.tableName = "users */ DROP TABLE users; /*"
+	sql, err = GenerateHardDeleteSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sql, "/* gen table=users____DROP_TABLE_users____ op=hard_delete */ ") {
+		t.Errorf("expect escaped table name, but got %s", sql)
+	}
+}
+
+func Test_GenerateSelectAllSQLTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("deleted_at", "TIMESTAMP", 4))
+
+	sql, err := GenerateSelectAllSQL(table, SQLOptions{TenantColumn: "tenant_id", OrderBy: []OrderColumn{{Column: "name"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY name, id`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectAllSQL(table, SQLOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users"`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	_, err = GenerateSelectAllSQL(testUsersTable(), SQLOptions{TenantColumn: "tenant_id"})
+	if err == nil {
+		t.Errorf("expect error for a missing tenant column")
+	}
+}
+
+func Test_SelectGeneratorsTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("attrs", "JSONB", 4))
+	orders := &dbTableMeta{
+		sqlType:     "postgres",
+		tableName:   "orders",
+		columns:     []*columnMeta{testColumn("id", "INT4", 0), testColumn("user_id", "INT4", 1), testColumn("tenant_id", "UUID", 2)},
+		foreignKeys: []ForeignKey{{Name: "orders_user", Table: "orders", Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}}},
+	}
+	orders.columns[0].isPrimaryKey = true
+
+	opt := SQLOptions{DetectTenant: true}
+	changed, err := GenerateSelectChangedSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		generate func() (string, error)
+		expected string
+	}{
+		{"csv", func() (string, error) { return GenerateSelectForCSVSQL(table, []string{"id", "name"}, opt) },
+			`SELECT id, name FROM "users" WHERE tenant_id = $1 ORDER BY id`},
+		{"json", func() (string, error) { return GenerateSelectJSONColumnSQL(table, "attrs", false, false, opt) },
+			`SELECT attrs FROM "users" WHERE id = $1 AND tenant_id = $2`},
+		{"lookup", func() (string, error) {
+			return GenerateSelectWithLookupSQL(orders, table, "user_id", "name", false, opt)
+		},
+			`SELECT t.*, ref.name AS user_id_label FROM "orders" t LEFT JOIN "users" ref ON ref.id = t.user_id WHERE t.id = $1 AND t.tenant_id = $2`},
+		{"polymorphic", func() (string, error) {
+			return GenerateSelectPolymorphicSQL(table, "name", "email", "post", false, opt)
+		},
+			`SELECT * FROM "users" WHERE name = 'post' AND email = $1 AND tenant_id = $2`},
+		{"changed", func() (string, error) { return changed.SQL, nil },
+			`SELECT 1 FROM "users" WHERE id = $1 AND tenant_id = $2 AND (name IS DISTINCT FROM $3 OR email IS DISTINCT FROM $4 OR attrs IS DISTINCT FROM $5)`},
+	}
+
+	for _, tt := range tests {
+		sql, err := tt.generate()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("%s expect: %s, but got %s", tt.name, tt.expected, sql)
+		}
+	}
+}
+
+func Test_GenerateSelectMultiChunkedSQLTenant(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("deleted_at", "TIMESTAMP", 4))
+
+	statements, err := GenerateSelectMultiChunkedSQL(table, 3, 2, SQLOptions{TenantColumn: "tenant_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`SELECT * FROM "users" WHERE id IN ($1, $2) AND tenant_id = $3 AND deleted_at IS NULL`,
+		`SELECT * FROM "users" WHERE id IN ($1) AND tenant_id = $2 AND deleted_at IS NULL`,
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("expect: %v, but got %v", expected, statements)
+	}
+
+	table.sqlType = "mysql"
+	_, err = GenerateSelectMultiChunkedSQL(table, 3, 2, SQLOptions{CastParams: true})
+	if err == nil {
+		t.Errorf("expect error for parameter casts on mysql")
+	}
+}
+
+func Test_SelectTenantAndSoftDelete(t *testing.T) {
+	table := testUsersTable()
+	table.columns = append(table.columns, testColumn("tenant_id", "UUID", 3), testColumn("deleted_at", "TIMESTAMP", 4))
+	opt := SQLOptions{DetectTenant: true}
+
+	stmt, err := GenerateSelectOneStatement(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `SELECT * FROM "users" WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+	if stmt.SQL != expected {
+		t.Errorf("expect: %s, but got %s", expected, stmt.SQL)
+	}
+	if stmt.TenantParam != 2 || stmt.Params[0].Column != "id" || stmt.Params[1].Column != "tenant_id" {
+		t.Errorf("expect id then tenant_id params, but got %v", stmt.Params)
+	}
+
+	sql, err := GenerateSelectByColumnsSQL(table, []string{"email"}, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE email = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectMultiSQL(table, false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE id = ANY($1::int4[]) AND tenant_id = $2 AND deleted_at IS NULL`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	table.sqlType = "mysql"
+	sql, err = GenerateSelectMultiSQL(table, false, SQLOptions{TenantColumn: "tenant_id", IDCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "SELECT * FROM `users` WHERE id IN (?, ?) AND tenant_id = ? AND deleted_at IS NULL"
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+
+	sql, err = GenerateSelectOneSQL(testUsersTable(), false, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `SELECT * FROM "users" WHERE id = $1`
+	if sql != expected {
+		t.Errorf("expect: %s, but got %s", expected, sql)
+	}
+}
@@ -0,0 +1,157 @@
+package dbmeta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// keyWhere builder for the primary key predicates of a where clause, each key column is compared using its own type so
+// composite keys of mixed types (e.g. tenant_id uuid, code text) are cast and matched per column
+type keyWhere struct {
+	d           Dialect
+	opt         SQLOptions
+	namedParams bool
+	pos         int
+	predicates  []string
+	params      []StatementParam
+}
+
+// newKeyWhere create a builder numbering the positional placeholders from startAt
+func newKeyWhere(d Dialect, opt SQLOptions, namedParams bool, startAt int) *keyWhere {
+	return &keyWhere{d: d, opt: opt, namedParams: namedParams, pos: startAt}
+}
+
+// paramType type the parameter of the column is cast to
+func paramType(col ColumnMeta) string {
+	return strings.ToLower(col.ColumnType())
+}
+
+// arrayParamType array type the parameter of an array column is cast to, e.g. text[] for a text[] or _text column. ok is
+// false if the column is not an array
+func arrayParamType(col ColumnMeta) (string, bool) {
+	columnType := paramType(col)
+	switch {
+	case strings.HasSuffix(columnType, "[]"):
+		return columnType, true
+	case strings.HasPrefix(columnType, "_"):
+		return columnType[1:] + "[]", true
+	case col.IsArray():
+		return columnType + "[]", true
+	}
+	return "", false
+}
+
+// placeholder next placeholder for the column, along with the name of the parameter
+func (w *keyWhere) placeholder(col ColumnMeta) (placeholder, name string) {
+	name = col.Name()
+	placeholder = w.d.Placeholder(w.pos)
+	if w.namedParams {
+		name = fmt.Sprintf("where_%s_%d", col.Name(), w.pos)
+		placeholder = w.d.NamedPlaceholder(name)
+	}
+	w.params = append(w.params, newStatementParam(name, col))
+	w.pos++
+	return placeholder, name
+}
+
+// addKey compare the key column with a parameter, cast to the column type if CastParams is set
+func (w *keyWhere) addKey(col ColumnMeta) {
+	placeholder, _ := w.placeholder(col)
+	if !w.namedParams {
+		placeholder = w.opt.castParam(col, placeholder)
+	}
+	w.predicates = append(w.predicates, w.opt.keyPredicate(w.d, col, placeholder))
+}
+
+// addTenant match the tenant column with a parameter, tenants are always compared exactly
+func (w *keyWhere) addTenant(col ColumnMeta) {
+	placeholder, _ := w.placeholder(col)
+	if !w.namedParams {
+		placeholder = w.opt.castParam(col, placeholder)
+	}
+	w.predicates = append(w.predicates, fmt.Sprintf("%s = %s", w.opt.columnRef(col.Name()), placeholder))
+}
+
+// addKeyArray match the key column against an array parameter of the column type
+func (w *keyWhere) addKeyArray(col ColumnMeta) {
+	placeholder, _ := w.placeholder(col)
+	w.predicates = append(w.predicates, fmt.Sprintf("%s = ANY(%s::%s[])", w.opt.columnRef(col.Name()), placeholder, paramType(col)))
+}
+
+// addBool match the boolean column with the literal of the dialect, the column must be boolean typed
+func (w *keyWhere) addBool(col ColumnMeta, value bool) error {
+	if !isBoolColumn(col) {
+		return fmt.Errorf("column %s of type %s is not a boolean, cannot generate sql", col.Name(), col.ColumnType())
+	}
+	w.predicates = append(w.predicates, fmt.Sprintf("%s = %s", w.opt.columnRef(col.Name()), boolLiteral(w.d, value)))
+	return nil
+}
+
+// isBoolColumn return true if the column type is a boolean, SQL Server bit columns hold booleans
+func isBoolColumn(col ColumnMeta) bool {
+	switch strings.ToLower(col.ColumnType()) {
+	case "bool", "boolean", "bit":
+		return true
+	}
+	return false
+}
+
+// boolLiteral literal of the boolean value in the dialect, SQL Server and Sqlite compare booleans with 1 and 0
+func boolLiteral(d Dialect, value bool) string {
+	switch d.Name() {
+	case "mssql", "sqlite3":
+		if value {
+			return "1"
+		}
+		return "0"
+	default:
+		if value {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+}
+
+// addBools match the boolean columns with their literal, in column name order so the sql is deterministic
+func (w *keyWhere) addBools(dbTable DbTableMeta, values map[string]bool) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		col, ok := findColumn(dbTable, name)
+		if !ok {
+			return fmt.Errorf("table %s does not have a boolean predicate column %s, cannot generate sql", dbTable.TableName(), name)
+		}
+
+		err := w.addBool(col, values[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// distinctFrom null safe predicate holding when the values differ, treating NULL as a value. Postgres uses IS DISTINCT
+// FROM, Sqlite IS NOT and MySQL NOT <=>, SQL Server compares the values and their nullness with the OR pattern.
+func distinctFrom(d Dialect, left, right string) string {
+	switch d.Name() {
+	case "sqlite3":
+		return fmt.Sprintf("%s IS NOT %s", left, right)
+	case "mysql":
+		return fmt.Sprintf("NOT (%s <=> %s)", left, right)
+	case "mssql":
+		return fmt.Sprintf("(%s <> %s OR (%s IS NULL AND %s IS NOT NULL) OR (%s IS NOT NULL AND %s IS NULL))",
+			left, right, left, right, left, right)
+	default:
+		return fmt.Sprintf("%s IS DISTINCT FROM %s", left, right)
+	}
+}
+
+// String predicates joined with AND
+func (w *keyWhere) String() string {
+	return strings.Join(w.predicates, " AND ")
+}
@@ -83,6 +83,31 @@ func (ci *columnMeta) IsAutoIncrement() bool {
 	return ci.isAutoIncrement
 }
 
+// EnumValues values of an enum column in declaration order, empty if the column is not an enum
+func (ci *columnMeta) EnumValues() []string {
+	return ci.enumValues
+}
+
+// IsJSON return if column is a json type, values should be scanned as raw json so a json null is not confused with a sql NULL
+func (ci *columnMeta) IsJSON() bool {
+	switch strings.ToLower(ci.databaseTypeName) {
+	case "json", "jsonb":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsUUID return if column is a uuid type, drivers may require the value to be bound as a uuid rather than a string
+func (ci *columnMeta) IsUUID() bool {
+	switch strings.ToLower(ci.databaseTypeName) {
+	case "uuid", "uniqueidentifier":
+		return true
+	default:
+		return false
+	}
+}
+
 type columnMeta struct {
 	index int
 	// ct              *sql.ColumnType
@@ -98,6 +123,7 @@ type columnMeta struct {
 	comment          string
 	databaseTypeName string
 	name             string
+	enumValues       []string
 }
 
 // ColumnType column type
@@ -179,6 +205,9 @@ type DbTableMeta interface {
 	SQLDatabase() string
 	TableName() string
 	DDL() string
+	Indexes() []Index
+	ForeignKeys() []ForeignKey
+	Comment() string
 }
 
 // ColumnMeta meta data for a column
@@ -192,11 +221,15 @@ type ColumnMeta interface {
 	IsPrimaryKey() bool
 	IsAutoIncrement() bool
 	IsArray() bool
+	IsUUID() bool
+	IsJSON() bool
+	EnumValues() []string
 	ColumnType() string
 	Notes() string
 	Comment() string
 	ColumnLength() int64
 	DefaultValue() string
+	ColDDL() string
 }
 
 type dbTableMeta struct {
@@ -206,6 +239,9 @@ type dbTableMeta struct {
 	columns       []*columnMeta
 	ddl           string
 	primaryKeyPos int
+	indexes       []Index
+	foreignKeys   []ForeignKey
+	comment       string
 }
 
 // PrimaryKeyPos ordinal pos of primary key
@@ -243,6 +279,21 @@ func (m *dbTableMeta) DDL() string {
 	return m.ddl
 }
 
+// Indexes secondary indexes of a sql table
+func (m *dbTableMeta) Indexes() []Index {
+	return m.indexes
+}
+
+// ForeignKeys foreign keys of a sql table
+func (m *dbTableMeta) ForeignKeys() []ForeignKey {
+	return m.foreignKeys
+}
+
+// Comment comment of a sql table
+func (m *dbTableMeta) Comment() string {
+	return m.comment
+}
+
 // ModelInfo info for a sql table
 type ModelInfo struct {
 	Index           int
@@ -29,6 +29,7 @@ func LoadMysqlMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTableM
 
 	m.ddl = ddl
 	colsDDL, primaryKeys := mysqlParseDDL(ddl)
+	m.comment = mysqlParseTableComment(ddl)
 
 	infoSchema, err := LoadTableInfoFromMSSqlInformationSchema(db, tableName)
 	if err != nil {
@@ -90,6 +91,7 @@ func LoadMysqlMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTableM
 			columnLen:        columnLen,
 			notes:            strings.Trim(notes, " "),
 			comment:          comment,
+			enumValues:       parseEnumValues(colDDL),
 		}
 
 		dbType := strings.ToLower(colMeta.DatabaseTypeName())
@@ -106,6 +108,21 @@ func LoadMysqlMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTableM
 	}
 
 	m = updateDefaultPrimaryKey(m)
+
+	// indexes are optional meta data, the table is loaded without them if they cannot be read
+	indexes, err := LoadIndexes(db, sqlType, sqlDatabase, tableName)
+	if err != nil {
+		fmt.Printf("Warning - unable to load indexes from %s table: %s error: %v\n", sqlType, tableName, err)
+	}
+	m.indexes = indexes
+
+	// foreign keys are optional meta data, e.g. the catalog may not be readable, the table is loaded without them
+	foreignKeys, err := LoadForeignKeys(db, sqlType, sqlDatabase, tableName)
+	if err != nil {
+		fmt.Printf("Warning - unable to load foreign keys from %s table: %s error: %v\n", sqlType, tableName, err)
+	}
+	m.foreignKeys = foreignKeys
+
 	return m, nil
 }
 
@@ -128,6 +145,17 @@ func mysqlLoadDDL(db *sql.DB, tableName string) (ddl string, err error) {
 	return ddl2, nil
 }
 
+var mysqlTableCommentRegex = regexp.MustCompile(`\)[^)]*COMMENT='((?:[^']|'')*)'[^)]*$`)
+
+// mysqlParseTableComment parse the table comment from the table options following the column definitions
+func mysqlParseTableComment(ddl string) string {
+	match := mysqlTableCommentRegex.FindStringSubmatch(ddl)
+	if match == nil {
+		return ""
+	}
+	return strings.Replace(match[1], "''", "'", -1)
+}
+
 func mysqlParseDDL(ddl string) (colsDDL map[string]string, primaryKeys []string) {
 	colsDDL = make(map[string]string)
 	lines := strings.Split(ddl, "\n")
@@ -34,6 +34,17 @@ func LoadPostgresMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTab
 		return nil, fmt.Errorf("unable to load primary key from postgres: %v", err)
 	}
 
+	enumValues, err := postgresLoadEnumValues(db, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load enum values from postgres: %v", err)
+	}
+
+	comments, err := postgresLoadComments(db, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load comments from postgres: %v", err)
+	}
+	m.comment = comments[""]
+
 	for i, v := range cols {
 		defaultVal := ""
 		nullable, ok := v.Nullable()
@@ -79,6 +90,8 @@ func LoadPostgresMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTab
 			columnLen:        maxLen,
 			columnType:       definedType,
 			defaultVal:       defaultVal,
+			enumValues:       enumValues[v.Name()],
+			comment:          comments[v.Name()],
 		}
 
 		m.columns[i] = colMeta
@@ -100,9 +113,83 @@ func LoadPostgresMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTab
 			v.isArray = true
 		}
 	}
+
+	// indexes are optional meta data, the table is loaded without them if they cannot be read
+	indexes, err := LoadIndexes(db, sqlType, sqlDatabase, tableName)
+	if err != nil {
+		fmt.Printf("Warning - unable to load indexes from %s table: %s error: %v\n", sqlType, tableName, err)
+	}
+	m.indexes = indexes
+
+	// foreign keys are optional meta data, e.g. the catalog may not be readable, the table is loaded without them
+	foreignKeys, err := LoadForeignKeys(db, sqlType, sqlDatabase, tableName)
+	if err != nil {
+		fmt.Printf("Warning - unable to load foreign keys from %s table: %s error: %v\n", sqlType, tableName, err)
+	}
+	m.foreignKeys = foreignKeys
+
 	return m, nil
 }
 
+// postgresLoadEnumValues fetch the values of the enum typed columns of the table from the catalog, keyed by column name
+func postgresLoadEnumValues(db *sql.DB, tableName string) (map[string][]string, error) {
+	enumSQL := `
+SELECT a.attname, e.enumlabel
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_enum e ON e.enumtypid = a.atttypid
+WHERE c.relname = $1 AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum, e.enumsortorder`
+
+	res, err := db.Query(enumSQL, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load enum values from %s: %v", tableName, err)
+	}
+	defer res.Close()
+
+	enumValues := make(map[string][]string)
+	for res.Next() {
+		var column, value string
+		err = res.Scan(&column, &value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load enum values from postgres Scan: %v", err)
+		}
+		enumValues[column] = append(enumValues[column], value)
+	}
+	return enumValues, res.Err()
+}
+
+// postgresLoadComments fetch the comments of the table and its columns, keyed by column name with the table comment
+// keyed by the empty name
+func postgresLoadComments(db *sql.DB, tableName string) (map[string]string, error) {
+	commentSQL := `
+SELECT '', obj_description(c.oid, 'pg_class')
+FROM pg_class c
+WHERE c.relname = $1 AND obj_description(c.oid, 'pg_class') IS NOT NULL
+UNION ALL
+SELECT a.attname, col_description(c.oid, a.attnum)
+FROM pg_class c
+JOIN pg_attribute a ON a.attrelid = c.oid
+WHERE c.relname = $1 AND a.attnum > 0 AND NOT a.attisdropped AND col_description(c.oid, a.attnum) IS NOT NULL`
+
+	res, err := db.Query(commentSQL, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load comments from %s: %v", tableName, err)
+	}
+	defer res.Close()
+
+	comments := make(map[string]string)
+	for res.Next() {
+		var name, comment string
+		err = res.Scan(&name, &comment)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load comments from postgres Scan: %v", err)
+		}
+		comments[name] = comment
+	}
+	return comments, res.Err()
+}
+
 func postgresLoadPrimaryKey(db *sql.DB, tableName string, colInfo map[string]*PostgresInformationSchema) error {
 	primaryKeySQL := fmt.Sprintf(`
 	SELECT c.column_name
@@ -85,6 +85,21 @@ func LoadSqliteMeta(db *sql.DB, sqlType, sqlDatabase, tableName string) (DbTable
 	}
 
 	m = updateDefaultPrimaryKey(m)
+
+	// indexes are optional meta data, the table is loaded without them if they cannot be read
+	indexes, err := LoadIndexes(db, sqlType, sqlDatabase, tableName)
+	if err != nil {
+		fmt.Printf("Warning - unable to load indexes from %s table: %s error: %v\n", sqlType, tableName, err)
+	}
+	m.indexes = indexes
+
+	// foreign keys are optional meta data, e.g. the catalog may not be readable, the table is loaded without them
+	foreignKeys, err := LoadForeignKeys(db, sqlType, sqlDatabase, tableName)
+	if err != nil {
+		fmt.Printf("Warning - unable to load foreign keys from %s table: %s error: %v\n", sqlType, tableName, err)
+	}
+	m.foreignKeys = foreignKeys
+
 	return m, nil
 }
 
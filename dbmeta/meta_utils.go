@@ -232,6 +232,23 @@ func cleanupDefault(val string) string {
 	return val
 }
 
+var enumDDLRegex = regexp.MustCompile(`(?i)^\s*enum\s*\(\s*('(?:[^']|'')*'(?:\s*,\s*'(?:[^']|'')*')*)\s*\)`)
+var enumValueRegex = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+// parseEnumValues parse the values of an enum('a','b') column definition
+func parseEnumValues(colDDL string) []string {
+	match := enumDDLRegex.FindStringSubmatch(colDDL)
+	if match == nil {
+		return nil
+	}
+
+	var values []string
+	for _, value := range enumValueRegex.FindAllStringSubmatch(match[1], -1) {
+		values = append(values, strings.Replace(value[1], "''", "'", -1))
+	}
+	return values
+}
+
 // BytesToString convert []uint8 to string
 func BytesToString(bs []uint8) string {
 	b := make([]byte, len(bs))